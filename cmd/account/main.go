@@ -5,7 +5,13 @@ import (
 	"fmt"
 	"os"
 
+	// register site scrapers
+	_ "reader/internal/app/reader/feeds/arknights"
+	_ "reader/internal/app/reader/feeds/genshin"
+	_ "reader/internal/app/reader/feeds/honkai3"
+
 	"reader/internal/app/reader/db"
+	"reader/internal/app/reader/feeds/feeds"
 	"reader/internal/app/reader/models"
 	"reader/internal/pkg/utils"
 )
@@ -36,12 +42,12 @@ func addAccount(email, password string) error {
 		return err
 	}
 
-	_, err = models.AddUser(email, password)
+	userID, err := models.AddUser(email, password)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return feeds.SubscribeFetchers(userID)
 }
 
 func main() {
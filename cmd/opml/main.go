@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"reader/internal/app/reader/db"
+	"reader/internal/app/reader/models"
+	"reader/internal/app/reader/opml"
+)
+
+func usage() {
+	fmt.Println("Usage:")
+	fmt.Println("  opml import <email> <file>    import subscriptions of account from OPML file")
+	fmt.Println("  opml export <email> [file]    export subscriptions of account to OPML file, standard output if omitted")
+}
+
+func getUserID(email string) (int64, error) {
+	user, err := models.GetUser(email)
+	if err != nil {
+		return 0, err
+	}
+	if user == nil {
+		return 0, fmt.Errorf("account %s not found", email)
+	}
+
+	return user.ID, nil
+}
+
+func exportSubscriptions(email, path string) error {
+	userID, err := getUserID(email)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return opml.Export(w, userID)
+}
+
+func importSubscriptions(email, path string) error {
+	userID, err := getUserID(email)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	result, err := opml.Import(f, userID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Added %d feeds, %d already existed, %d skipped\n", result.Added, result.Existing, result.Skipped)
+	return nil
+}
+
+func main() {
+	if len(os.Args) < 3 {
+		usage()
+		os.Exit(1)
+	}
+
+	pg := db.SetupDatabase()
+	defer db.CloseDatabase(pg)
+
+	var err error
+	switch os.Args[1] {
+	case "import":
+		if len(os.Args) != 4 {
+			usage()
+			os.Exit(1)
+		}
+		err = importSubscriptions(os.Args[2], os.Args[3])
+	case "export":
+		path := ""
+		if len(os.Args) > 3 {
+			path = os.Args[3]
+		}
+		err = exportSubscriptions(os.Args[2], path)
+	default:
+		usage()
+		os.Exit(1)
+	}
+
+	if err != nil {
+		panic(err)
+	}
+}
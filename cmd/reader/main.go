@@ -2,13 +2,17 @@ package main
 
 import (
 	"os"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 
 	"reader/internal/app/reader/db"
 	"reader/internal/app/reader/feeds"
+	"reader/internal/app/reader/models"
+	"reader/internal/app/reader/retention"
 	"reader/internal/app/reader/routes"
+	"reader/internal/app/reader/webhooks"
 	"reader/internal/pkg/utils"
 )
 
@@ -19,6 +23,18 @@ const (
 // SetupRouter builds the router
 func SetupRouter() *gin.Engine {
 	router := gin.New()
+
+	// client IP is taken from forwarded headers of trusted proxies only
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("APP_TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	if err := router.SetTrustedProxies(proxies); err != nil {
+		panic("invalid trusted proxies")
+	}
+
 	router.Use(gin.LoggerWithWriter(gin.DefaultWriter, "/ping"), gin.Recovery())
 	routes.SetupRoutes(router)
 	return router
@@ -44,7 +60,25 @@ func main() {
 	pg := db.SetupDatabase()
 	defer db.CloseDatabase(pg)
 
+	// entries added before search are indexed in background
+	go func() {
+		count, err := models.IndexEntries()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Error("Index entries")
+			return
+		}
+		if count > 0 {
+			log.WithFields(log.Fields{
+				"count": count,
+			}).Info("Index entries")
+		}
+	}()
+
 	feeds.LoadFeeds()
+	webhooks.Start()
+	retention.Start()
 
 	router := SetupRouter()
 	router.Run(":3000")
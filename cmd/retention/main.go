@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"strconv"
+	"time"
+
+	"reader/internal/app/reader/db"
+	"reader/internal/app/reader/models"
+	"reader/internal/app/reader/retention"
+)
+
+func usage() {
+	fmt.Println("Usage:")
+	fmt.Println("  retention list                         list retention policies of feeds")
+	fmt.Println("  retention purge [--dry-run]            purge entries by retention policies, dry run reports without purging")
+	fmt.Println("  retention set <feed id> <count> <days> set retention of feed, - takes the global setting, 0 keeps all")
+}
+
+// parseSetting parses a retention setting of feed, nil for -
+func parseSetting(v string) (*int32, error) {
+	if v == "-" {
+		return nil, nil
+	}
+
+	n, err := strconv.ParseInt(v, 10, 32)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid setting %s", v)
+	}
+	setting := int32(n)
+
+	return &setting, nil
+}
+
+func formatSetting(setting *int32) string {
+	if setting == nil {
+		return "-"
+	}
+
+	return strconv.Itoa(int(*setting))
+}
+
+func listPolicies() error {
+	feeds, err := models.ListFeeds()
+	if err != nil {
+		return err
+	}
+
+	global := retention.Global()
+	fmt.Printf("Global: %s\n", global)
+	fmt.Printf("%-6s  %-6s  %-6s  %-28s  %s\n", "ID", "COUNT", "DAYS", "POLICY", "FEED")
+	for _, feed := range feeds {
+		fmt.Printf("%-6d  %-6s  %-6s  %-28s  %s\n",
+			feed.ID,
+			formatSetting(feed.RetentionCount),
+			formatSetting(feed.RetentionDays),
+			retention.FeedPolicy(feed, global),
+			html.UnescapeString(feed.Name))
+	}
+
+	return nil
+}
+
+func purge(dryRun bool) error {
+	reports, err := retention.Run(time.Now(), dryRun)
+
+	verb := "Purged"
+	if dryRun {
+		verb = "Would purge"
+	}
+	var total int64
+	for _, report := range reports {
+		fmt.Printf("%s %d entries of %s (%d), %s\n", verb, report.Count, report.FeedName, report.FeedID, report.Policy)
+		total += report.Count
+	}
+	fmt.Printf("%s %d entries in total\n", verb, total)
+
+	return err
+}
+
+func setPolicy(id, count, days string) error {
+	feedID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid feed id %s", id)
+	}
+	feed, err := models.GetFeed(feedID)
+	if err != nil {
+		return err
+	}
+	if feed == nil {
+		return fmt.Errorf("feed %d not found", feedID)
+	}
+
+	retentionCount, err := parseSetting(count)
+	if err != nil {
+		return err
+	}
+	retentionDays, err := parseSetting(days)
+	if err != nil {
+		return err
+	}
+
+	if err := models.UpdateFeedRetention(feedID, retentionCount, retentionDays); err != nil {
+		return err
+	}
+	feed.RetentionCount = retentionCount
+	feed.RetentionDays = retentionDays
+
+	fmt.Printf("%s: %s\n", html.UnescapeString(feed.Name), retention.FeedPolicy(feed, retention.Global()))
+	return nil
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	pg := db.SetupDatabase()
+	defer db.CloseDatabase(pg)
+
+	var err error
+	switch os.Args[1] {
+	case "list":
+		err = listPolicies()
+	case "purge":
+		dryRun := false
+		if len(os.Args) > 2 {
+			if os.Args[2] != "--dry-run" {
+				usage()
+				os.Exit(1)
+			}
+			dryRun = true
+		}
+		err = purge(dryRun)
+	case "set":
+		if len(os.Args) != 5 {
+			usage()
+			os.Exit(1)
+		}
+		err = setPolicy(os.Args[2], os.Args[3], os.Args[4])
+	default:
+		usage()
+		os.Exit(1)
+	}
+
+	if err != nil {
+		panic(err)
+	}
+}
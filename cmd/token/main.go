@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+
+	"reader/internal/app/reader/db"
+	"reader/internal/app/reader/models"
+	"reader/internal/pkg/utils"
+)
+
+const (
+	cliUserAgent = "token-cli" // recorded in auth events
+	timeFormat   = "2006-01-02 15:04:05"
+)
+
+func usage() {
+	fmt.Println("Usage:")
+	fmt.Println("  token list <email>                  list API tokens of account")
+	fmt.Println("  token issue <email> <label> [ttl]   issue API token of account, e.g. ttl 720h, never expires if omitted")
+	fmt.Println("  token fever <email>                 enable Fever API key of account, password is read from stdin")
+	fmt.Println("  token revoke <email> <id>           revoke API token of account")
+}
+
+func getUser(email string) (*models.User, error) {
+	user, err := models.GetUser(email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("account %s not found", email)
+	}
+
+	return user, nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+
+	return t.Local().Format(timeFormat)
+}
+
+func listTokens(email string) error {
+	user, err := getUser(email)
+	if err != nil {
+		return err
+	}
+
+	authTokens, err := models.ListAuthTokens(user.ID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-6s  %-5s  %-19s  %-19s  %-19s  %s\n", "ID", "SCOPE", "CREATED", "LAST USED", "EXPIRES", "LABEL")
+	for _, authToken := range authTokens {
+		fmt.Printf("%-6d  %-5s  %-19s  %-19s  %-19s  %s\n",
+			authToken.ID,
+			authToken.Scope,
+			formatTime(&authToken.CreatedAt),
+			formatTime(authToken.LastUsedAt),
+			formatTime(authToken.ExpiresAt),
+			authToken.Label)
+	}
+
+	return nil
+}
+
+func issueToken(email, label, ttl string) error {
+	user, err := getUser(email)
+	if err != nil {
+		return err
+	}
+
+	var duration time.Duration
+	if ttl != "" {
+		if duration, err = time.ParseDuration(ttl); err != nil || duration <= 0 {
+			return fmt.Errorf("invalid ttl %s", ttl)
+		}
+	}
+
+	token, authToken, err := models.IssueAuthToken(user.ID, label, duration)
+	if err != nil {
+		return err
+	}
+
+	if err := models.AddAuthEvent(&models.AuthEvent{
+		Event:       models.AuthEventTokenIssue,
+		UserAgent:   cliUserAgent,
+		AuthTokenID: &authToken.ID,
+		UserID:      &user.ID,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Issued token %d for %s, it is shown only once:\n%s\n", authToken.ID, email, token)
+	return nil
+}
+
+// readPassword reads password from stdin, without echo when stdin is a terminal
+func readPassword() (string, error) {
+	fmt.Print("Password: ")
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		password, err := term.ReadPassword(fd)
+		fmt.Println()
+		return string(password), err
+	}
+
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	return strings.TrimRight(password, "\r\n"), nil
+}
+
+// enableFever registers the Fever API key of account, which is md5 of "email:password"
+func enableFever(email string) error {
+	user, err := getUser(email)
+	if err != nil {
+		return err
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+	if password == "" {
+		return fmt.Errorf("empty password of %s", email)
+	}
+
+	if !utils.VerifyPassword(password, user.Password) {
+		return fmt.Errorf("invalid password of %s", email)
+	}
+
+	apiKey := utils.Md5(fmt.Sprintf("%s:%s", user.Email, password))
+	existing, err := models.GetAuthToken(apiKey)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if existing.Scope != models.AuthTokenScopeFever {
+			return fmt.Errorf("Fever API key of %s is taken by token %d", email, existing.ID)
+		}
+		fmt.Printf("Fever API key of %s is already enabled as token %d\n", email, existing.ID)
+		return nil
+	}
+
+	authToken, err := models.AddAuthToken(user.ID, apiKey, "Fever", models.AuthTokenScopeFever, 0)
+	if err != nil {
+		return err
+	}
+	if err := models.AddAuthEvent(&models.AuthEvent{
+		Event:       models.AuthEventTokenIssue,
+		UserAgent:   cliUserAgent,
+		AuthTokenID: &authToken.ID,
+		UserID:      &user.ID,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Enabled Fever API key of %s as token %d\n", email, authToken.ID)
+	return nil
+}
+
+func revokeToken(email, id string) error {
+	user, err := getUser(email)
+	if err != nil {
+		return err
+	}
+
+	tokenID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid token ID %s", id)
+	}
+
+	count, err := models.DeleteAuthToken(user.ID, tokenID)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("token %d of %s not found", tokenID, email)
+	}
+
+	if err := models.AddAuthEvent(&models.AuthEvent{
+		Event:       models.AuthEventTokenRevoke,
+		UserAgent:   cliUserAgent,
+		AuthTokenID: &tokenID,
+		UserID:      &user.ID,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Revoked token %d of %s\n", tokenID, email)
+	return nil
+}
+
+func main() {
+	if len(os.Args) < 3 {
+		usage()
+		os.Exit(1)
+	}
+
+	pg := db.SetupDatabase()
+	defer db.CloseDatabase(pg)
+
+	var err error
+	switch os.Args[1] {
+	case "list":
+		err = listTokens(os.Args[2])
+	case "issue":
+		if len(os.Args) < 4 || len(os.Args) > 5 {
+			usage()
+			os.Exit(1)
+		}
+		ttl := ""
+		if len(os.Args) == 5 {
+			ttl = os.Args[4]
+		}
+		err = issueToken(os.Args[2], os.Args[3], ttl)
+	case "fever":
+		if len(os.Args) != 3 {
+			usage()
+			os.Exit(1)
+		}
+		err = enableFever(os.Args[2])
+	case "revoke":
+		if len(os.Args) != 4 {
+			usage()
+			os.Exit(1)
+		}
+		err = revokeToken(os.Args[2], os.Args[3])
+	default:
+		usage()
+		os.Exit(1)
+	}
+
+	if err != nil {
+		panic(err)
+	}
+}
@@ -43,6 +43,9 @@ func SetupDatabase() *gorm.DB {
 
 	tables := models.Initialize(db)
 	postgres.SyncTables(db, tables)
+	if err := models.Migrate(); err != nil {
+		panic("failed to migrate database")
+	}
 
 	return db
 }
@@ -0,0 +1,185 @@
+package events
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	TypeEntries   = "entries" // new entries of feed
+	TypeRead      = "read"
+	TypeUnread    = "unread"
+	TypeStarred   = "starred"
+	TypeUnstarred = "unstarred"
+	TypeTagged    = "tagged"
+	TypeUntagged  = "untagged"
+	TypeDeleted   = "deleted" // entries deleted by rule of user
+	TypeNotify    = "notify"  // new entries matched by rule of user with notify action
+)
+
+const (
+	bufferSize     = 1024 // recent events kept for resuming
+	subscriberSize = 64   // pending events of subscriber before it is dropped
+)
+
+// Event entry change event of users
+type Event struct {
+	ID       string  `json:"-"` // epoch-sequence, set by broker
+	Type     string  `json:"type"`
+	EntryIDs []int64 `json:"entryIds"`
+	FeedID   int64   `json:"feedId,omitempty"`
+	Rule     string  `json:"rule,omitempty"`
+	Tag      string  `json:"tag,omitempty"`
+
+	seq     uint64
+	userIDs map[int64]struct{}
+}
+
+// For returns true if event is visible to user
+func (e *Event) For(userID int64) bool {
+	_, ok := e.userIDs[userID]
+	return ok
+}
+
+// Subscriber receives events of user, Events is closed when subscriber is dropped for falling behind
+type Subscriber struct {
+	Events <-chan *Event
+
+	ch     chan *Event
+	userID int64
+}
+
+// Broker fans out events to subscribers and keeps recent events for resuming
+type Broker struct {
+	epoch string
+
+	mutex       sync.Mutex
+	buffer      []*Event
+	seq         uint64
+	subscribers map[*Subscriber]struct{}
+}
+
+// NewBroker creates broker, event IDs of different brokers never match
+func NewBroker() *Broker {
+	return &Broker{
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		subscribers: make(map[*Subscriber]struct{}),
+	}
+}
+
+// Listening returns true if anyone subscribes, so publishers can skip preparing events
+func (b *Broker) Listening() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return len(b.subscribers) > 0
+}
+
+// Publish publishes event to users
+func (b *Broker) Publish(event *Event, userIDs ...int64) {
+	if len(userIDs) == 0 {
+		return
+	}
+
+	event.userIDs = make(map[int64]struct{}, len(userIDs))
+	for _, userID := range userIDs {
+		event.userIDs[userID] = struct{}{}
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.seq++
+	event.seq = b.seq
+	event.ID = fmt.Sprintf("%s-%d", b.epoch, b.seq)
+
+	b.buffer = append(b.buffer, event)
+	if len(b.buffer) > bufferSize {
+		b.buffer = b.buffer[len(b.buffer)-bufferSize:]
+	}
+
+	for s := range b.subscribers {
+		if !event.For(s.userID) {
+			continue
+		}
+		select {
+		case s.ch <- event:
+		default:
+			// the client resumes with its last event ID after reconnecting
+			delete(b.subscribers, s)
+			close(s.ch)
+		}
+	}
+}
+
+// Subscribe subscribes events of user after last event ID, returns the missed events,
+// false if they are unavailable so the client should reload its state
+func (b *Broker) Subscribe(userID int64, lastEventID string) (*Subscriber, []*Event, bool) {
+	ch := make(chan *Event, subscriberSize)
+	s := &Subscriber{Events: ch, ch: ch, userID: userID}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.subscribers[s] = struct{}{}
+
+	if lastEventID == "" {
+		return s, nil, true
+	}
+
+	epoch, v, _ := strings.Cut(lastEventID, "-")
+	seq, err := strconv.ParseUint(v, 10, 64)
+	if err != nil || epoch != b.epoch || seq > b.seq {
+		return s, nil, false
+	}
+	// events after seq have been dropped from buffer
+	if len(b.buffer) > 0 && b.buffer[0].seq > seq+1 {
+		return s, nil, false
+	}
+
+	var missed []*Event
+	for _, event := range b.buffer {
+		if event.seq > seq && event.For(userID) {
+			missed = append(missed, event)
+		}
+	}
+
+	return s, missed, true
+}
+
+// Unsubscribe removes subscriber
+func (b *Broker) Unsubscribe(s *Subscriber) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, ok := b.subscribers[s]; ok {
+		delete(b.subscribers, s)
+		close(s.ch)
+	}
+}
+
+var defaultBroker = NewBroker()
+
+// Listening returns true if anyone subscribes to the default broker
+func Listening() bool {
+	return defaultBroker.Listening()
+}
+
+// Publish publishes event to users with the default broker
+func Publish(event *Event, userIDs ...int64) {
+	defaultBroker.Publish(event, userIDs...)
+}
+
+// Subscribe subscribes events of user with the default broker
+func Subscribe(userID int64, lastEventID string) (*Subscriber, []*Event, bool) {
+	return defaultBroker.Subscribe(userID, lastEventID)
+}
+
+// Unsubscribe removes subscriber of the default broker
+func Unsubscribe(s *Subscriber) {
+	defaultBroker.Unsubscribe(s)
+}
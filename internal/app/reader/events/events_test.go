@@ -0,0 +1,83 @@
+package events
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrokerPublish(t *testing.T) {
+	b := NewBroker()
+	assert.False(t, b.Listening())
+
+	s1, _, ok := b.Subscribe(1, "")
+	assert.True(t, ok)
+	s2, _, _ := b.Subscribe(2, "")
+	assert.True(t, b.Listening())
+
+	b.Publish(&Event{Type: TypeRead, EntryIDs: []int64{10}}, 1)
+	b.Publish(&Event{Type: TypeEntries, EntryIDs: []int64{11}, FeedID: 3}, 1, 2)
+
+	event := <-s1.Events
+	assert.Equal(t, TypeRead, event.Type)
+	assert.Equal(t, fmt.Sprintf("%s-1", b.epoch), event.ID)
+	assert.Equal(t, TypeEntries, (<-s1.Events).Type)
+	assert.Equal(t, TypeEntries, (<-s2.Events).Type)
+	assert.Len(t, s2.Events, 0)
+
+	b.Unsubscribe(s1)
+	b.Unsubscribe(s2)
+	assert.False(t, b.Listening())
+	_, ok = <-s1.Events
+	assert.False(t, ok)
+}
+
+func TestBrokerResume(t *testing.T) {
+	b := NewBroker()
+	for i := 0; i < 3; i++ {
+		b.Publish(&Event{Type: TypeStarred, EntryIDs: []int64{int64(i)}}, 1)
+	}
+	b.Publish(&Event{Type: TypeStarred}, 2)
+
+	s, missed, ok := b.Subscribe(1, fmt.Sprintf("%s-1", b.epoch))
+	assert.True(t, ok)
+	if assert.Len(t, missed, 2) {
+		assert.Equal(t, []int64{1}, missed[0].EntryIDs)
+		assert.Equal(t, []int64{2}, missed[1].EntryIDs)
+	}
+	b.Unsubscribe(s)
+
+	for _, lastEventID := range []string{"other-1", "invalid", fmt.Sprintf("%s-9", b.epoch)} {
+		s, missed, ok = b.Subscribe(1, lastEventID)
+		assert.False(t, ok, lastEventID)
+		assert.Nil(t, missed, lastEventID)
+		b.Unsubscribe(s)
+	}
+
+	for i := 0; i < bufferSize; i++ {
+		b.Publish(&Event{Type: TypeRead}, 1)
+	}
+	s, _, ok = b.Subscribe(1, fmt.Sprintf("%s-1", b.epoch))
+	assert.False(t, ok)
+	b.Unsubscribe(s)
+}
+
+func TestBrokerDropsSlowSubscriber(t *testing.T) {
+	b := NewBroker()
+	s, _, _ := b.Subscribe(1, "")
+
+	for i := 0; i <= subscriberSize; i++ {
+		b.Publish(&Event{Type: TypeRead}, 1)
+	}
+	assert.False(t, b.Listening())
+
+	count := 0
+	for range s.Events {
+		count++
+	}
+	assert.Equal(t, subscriberSize, count)
+
+	// unsubscribing a dropped subscriber is safe
+	b.Unsubscribe(s)
+}
@@ -2,14 +2,13 @@ package arknights
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"strings"
 	"time"
 
-	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/html"
 
 	"reader/internal/app/reader/feeds/feeds"
@@ -44,23 +43,13 @@ func (a *articleItem) gUID() string {
 	return fmt.Sprintf("https://ak.hypergryph.com/news/%s", a.ID)
 }
 
-func (a *articleItem) parseToEntry(feedID int64) (*models.Entry, error) {
+func (a *articleItem) parseToEntry() (*models.Entry, error) {
 	entry := &models.Entry{
-		Favorite: false,
-		GUID:     a.gUID(),
-		Link:     fmt.Sprintf("https://ak.hypergryph.com/news/%s.html", a.ID),
-		Read:     false,
-		Title:    a.Title,
-		FeedID:   feedID,
+		GUID:  a.gUID(),
+		Link:  fmt.Sprintf("https://ak.hypergryph.com/news/%s.html", a.ID),
+		Title: a.Title,
 	}
 
-	author, content, err := fetchAuthorAndContent(entry.Link)
-	if err != nil {
-		return nil, err
-	}
-	entry.Author = author
-	entry.Content = content
-
 	publish, err := time.ParseInLocation(timeFormat, a.Date, utils.Beijing)
 	if err != nil {
 		return nil, err
@@ -70,70 +59,56 @@ func (a *articleItem) parseToEntry(feedID int64) (*models.Entry, error) {
 	return entry, nil
 }
 
-// Fetch fetches Arknights official news articles
-func Fetch() error {
-	categoryID, err := feeds.SetupCategory(feeds.GamesCategoryName)
-	if err != nil {
-		return err
-	}
+type fetcher struct{}
 
-	feedID, err := feeds.SetupFeed(categoryID, feedName, feedPriority, contentURL, contentURL)
-	if err != nil {
-		return err
-	}
+func init() {
+	feeds.Register(&fetcher{})
+}
 
-	log.WithFields(log.Fields{
-		"feed": feedName,
-	}).Info("Fetch")
+// Name returns the fetcher name
+func (f *fetcher) Name() string {
+	return "arknights"
+}
 
-	items, err := fetchArticleList()
-	if err != nil {
-		return err
+// Meta returns the feed metadata
+func (f *fetcher) Meta() *feeds.Meta {
+	return &feeds.Meta{
+		Category: feeds.GamesCategoryName,
+		DateOnly: true,
+		Name:     feedName,
+		Priority: feedPriority,
+		URL:      contentURL,
+		Website:  contentURL,
 	}
-	if len(items) == 0 {
-		return nil
+}
+
+// Fetch fetches Arknights official news articles
+func (f *fetcher) Fetch(ctx context.Context) ([]*models.Entry, error) {
+	items, err := fetchArticleList(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	var deduplicateItems []*articleItem
-	idMap := make(map[string]struct{})
+	var entries []*models.Entry
 	for _, item := range items {
-		if _, ok := idMap[item.ID]; !ok {
-			idMap[item.ID] = struct{}{}
-			deduplicateItems = append(deduplicateItems, item)
+		entry, err := item.parseToEntry()
+		if err != nil {
+			return nil, err
 		}
+		entries = append(entries, entry)
 	}
 
-	var gUIDs []string
-	for _, item := range deduplicateItems {
-		gUIDs = append(gUIDs, item.gUID())
-	}
+	return entries, nil
+}
 
-	existingGUIDs, err := models.ExistingGUIDs(gUIDs)
+// Complete fetches author and content of the article
+func (f *fetcher) Complete(ctx context.Context, entry *models.Entry) error {
+	author, content, err := fetchAuthorAndContent(ctx, entry.Link)
 	if err != nil {
 		return err
 	}
-
-	if len(existingGUIDs) == len(gUIDs) {
-		return nil
-	}
-
-	gUIDMap := make(map[string]struct{}, len(existingGUIDs))
-	for _, gUID := range existingGUIDs {
-		gUIDMap[gUID] = struct{}{}
-	}
-
-	for i := len(deduplicateItems) - 1; i >= 0; i-- {
-		item := deduplicateItems[i]
-		if _, ok := gUIDMap[item.gUID()]; !ok {
-			entry, err := item.parseToEntry(feedID)
-			if err != nil {
-				return err
-			}
-			if _, err := models.AddEntryWithDateCount(entry); err != nil {
-				return err
-			}
-		}
-	}
+	entry.Author = author
+	entry.Content = content
 
 	return nil
 }
@@ -159,14 +134,8 @@ func extractArticleAndContentNodes(n, a, c *html.Node) (*html.Node, *html.Node)
 	return a, c
 }
 
-func fetchArticleList() ([]*articleItem, error) {
-	resp, err := http.Get(contentURL)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+func fetchArticleList(ctx context.Context) ([]*articleItem, error) {
+	body, err := feeds.Get(ctx, contentURL)
 	if err != nil {
 		return nil, err
 	}
@@ -204,14 +173,8 @@ func fetchArticleList() ([]*articleItem, error) {
 	}
 }
 
-func fetchAuthorAndContent(url string) (string, string, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return "", "", err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+func fetchAuthorAndContent(ctx context.Context, url string) (string, string, error) {
+	body, err := feeds.Get(ctx, url)
 	if err != nil {
 		return "", "", err
 	}
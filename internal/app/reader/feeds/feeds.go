@@ -1,26 +1,134 @@
 package feeds
 
 import (
+	"context"
+	"sync"
 	"time"
 
-	"reader/internal/app/reader/feeds/arknights"
-	"reader/internal/app/reader/feeds/genshin"
-	"reader/internal/app/reader/feeds/honkai3"
+	log "github.com/sirupsen/logrus"
+
+	// register site scrapers
+	_ "reader/internal/app/reader/feeds/arknights"
+	_ "reader/internal/app/reader/feeds/genshin"
+	_ "reader/internal/app/reader/feeds/honkai3"
+
+	"reader/internal/app/reader/feeds/feeds"
+	"reader/internal/app/reader/feeds/generic"
+	"reader/internal/app/reader/models"
 )
 
 const (
-	interval = 600 * time.Second
+	tick    = 30 * time.Second
+	workers = 8
 )
 
+type scheduler struct {
+	inFlight      map[int64]struct{}
+	inFlightMutex sync.Mutex
+	jobs          chan *models.Feed
+}
+
 // LoadFeeds loads all feeds
 func LoadFeeds() {
-	go func() {
-		for {
-			// TODO: catch error return value with channel
-			go arknights.Fetch()
-			go genshin.Fetch()
-			go honkai3.Fetch()
-			<-time.After(interval)
+	if err := feeds.SetupClient(); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("Setup HTTP client")
+	}
+
+	for _, f := range feeds.Fetchers() {
+		if _, err := feeds.Setup(f); err != nil {
+			log.WithFields(log.Fields{
+				"fetcher": f.Name(),
+				"error":   err,
+			}).Error("Setup feed")
+		}
+	}
+
+	s := &scheduler{
+		inFlight: make(map[int64]struct{}),
+		jobs:     make(chan *models.Feed),
+	}
+
+	ctx := context.Background()
+	for i := 0; i < workers; i++ {
+		go s.work(ctx)
+	}
+	go s.run()
+}
+
+// fetcherFor returns the fetcher of feed, feeds without a registered scraper use the generic fetcher
+func fetcherFor(feed *models.Feed) feeds.Fetcher {
+	if f, ok := feeds.Lookup(feed.URL); ok {
+		return f
+	}
+
+	return generic.New(feed)
+}
+
+func (s *scheduler) dispatch() {
+	dueFeeds, err := models.ListDueFeeds(time.Now())
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("List due feeds")
+		return
+	}
+
+	for _, feed := range dueFeeds {
+		s.inFlightMutex.Lock()
+		_, ok := s.inFlight[feed.ID]
+		if !ok {
+			s.inFlight[feed.ID] = struct{}{}
+		}
+		s.inFlightMutex.Unlock()
+
+		if !ok {
+			s.jobs <- feed
 		}
+	}
+}
+
+func (s *scheduler) process(ctx context.Context, feed *models.Feed) {
+	defer func() {
+		s.inFlightMutex.Lock()
+		delete(s.inFlight, feed.ID)
+		s.inFlightMutex.Unlock()
 	}()
+
+	f := fetcherFor(feed)
+
+	count, err := feeds.Ingest(ctx, feed.ID, f)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"feed":     feed.Name,
+			"fetcher":  f.Name(),
+			"failures": feed.FailureCount + 1,
+			"error":    err,
+		}).Warn("Fetch failed")
+	}
+
+	reschedule(feed, count, err, time.Now())
+	if err := models.UpdateFeedState(feed); err != nil {
+		log.WithFields(log.Fields{
+			"feed":  feed.Name,
+			"error": err,
+		}).Error("Update feed state")
+	}
+}
+
+func (s *scheduler) run() {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		s.dispatch()
+		<-ticker.C
+	}
+}
+
+func (s *scheduler) work(ctx context.Context) {
+	for feed := range s.jobs {
+		s.process(ctx, feed)
+	}
 }
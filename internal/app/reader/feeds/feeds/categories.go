@@ -6,18 +6,19 @@ import (
 
 // Categories
 const (
-	GamesCategoryName = "Games"
+	GamesCategoryName         = "Games"
+	UncategorizedCategoryName = "Uncategorized"
 )
 
-// SetupCategory setups category
-func SetupCategory(name string) (int64, error) {
-	categoryID, err := models.GetCategoryIDForName(name)
+// SetupCategory setups category of user, models.SharedUserID for shared category
+func SetupCategory(userID int64, name string) (int64, error) {
+	categoryID, err := models.GetCategoryIDForName(userID, name)
 	if err != nil {
 		return 0, err
 	}
 
 	if categoryID == -1 {
-		if categoryID, err = models.AddCategory(name); err != nil {
+		if categoryID, err = models.AddCategory(userID, name); err != nil {
 			return 0, err
 		}
 	}
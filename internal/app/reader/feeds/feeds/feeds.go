@@ -4,7 +4,7 @@ import (
 	"reader/internal/app/reader/models"
 )
 
-// SetupFeed setups feed
+// SetupFeed setups feed, a new feed is subscribed by all users
 func SetupFeed(categoryID int64, name string, priority int8, url, website string) (int64, error) {
 	feedID, err := models.GetFeedIDForURL(url)
 	if err != nil {
@@ -15,7 +15,39 @@ func SetupFeed(categoryID int64, name string, priority int8, url, website string
 		if feedID, err = models.AddFeed(name, priority, url, website, categoryID); err != nil {
 			return 0, err
 		}
+		if err := models.AddSubscriptionForAllUsers(feedID, categoryID, priority); err != nil {
+			return 0, err
+		}
 	}
 
 	return feedID, nil
 }
+
+// SubscribeFetchers subscribes user to feeds of all registered fetchers
+func SubscribeFetchers(userID int64) error {
+	for _, f := range Fetchers() {
+		meta := f.Meta()
+
+		categoryID, err := SetupCategory(models.SharedUserID, meta.Category)
+		if err != nil {
+			return err
+		}
+
+		feedID, err := SetupFeed(categoryID, meta.Name, meta.Priority, meta.URL, meta.Website)
+		if err != nil {
+			return err
+		}
+
+		subscription, err := models.GetSubscription(userID, feedID)
+		if err != nil {
+			return err
+		}
+		if subscription == nil {
+			if _, err := models.AddSubscription(userID, feedID, categoryID, meta.Priority, ""); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
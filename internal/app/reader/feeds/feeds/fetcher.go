@@ -0,0 +1,139 @@
+package feeds
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"reader/internal/app/reader/models"
+)
+
+// Meta feed metadata
+type Meta struct {
+	Category string
+	DateOnly bool // entry dates have day precision, entries of a day are spread by insertion order
+	Name     string
+	Priority int8
+	URL      string
+	Website  string
+}
+
+// maxPages pages fetched by FetchPages at most, bounds first runs and catch-ups after downtime
+const maxPages = 10
+
+// Fetcher fetches entries of a feed
+type Fetcher interface {
+	// Name returns the unique name of the fetcher
+	Name() string
+	// Meta returns the feed metadata
+	Meta() *Meta
+	// Fetch fetches the latest entries, newest first, FeedID is filled by the pipeline
+	Fetch(ctx context.Context) ([]*models.Entry, error)
+}
+
+// Completer is implemented by fetchers which defer expensive work, such as
+// downloading article content, until the pipeline knows that the entry is new
+type Completer interface {
+	Complete(ctx context.Context, entry *models.Entry) error
+}
+
+var (
+	registry      = make(map[string]Fetcher)
+	registryMutex sync.RWMutex
+)
+
+// Fetchers returns all registered fetchers sorted by name
+func Fetchers() []Fetcher {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	fetchers := make([]Fetcher, 0, len(registry))
+	for _, f := range registry {
+		fetchers = append(fetchers, f)
+	}
+	sort.Slice(fetchers, func(i, j int) bool {
+		return fetchers[i].Name() < fetchers[j].Name()
+	})
+
+	return fetchers
+}
+
+// Lookup returns the registered fetcher serving URL
+func Lookup(url string) (Fetcher, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	for _, f := range registry {
+		if f.Meta().URL == url {
+			return f, true
+		}
+	}
+
+	return nil, false
+}
+
+// Register registers fetcher, panics if the name is registered twice
+func Register(f Fetcher) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if _, ok := registry[f.Name()]; ok {
+		panic(fmt.Sprintf("fetcher %s registered twice", f.Name()))
+	}
+	registry[f.Name()] = f
+}
+
+// Setup setups category and feed of fetcher, returns the feed ID
+func Setup(f Fetcher) (int64, error) {
+	meta := f.Meta()
+
+	categoryID, err := SetupCategory(models.SharedUserID, meta.Category)
+	if err != nil {
+		return 0, err
+	}
+
+	return SetupFeed(categoryID, meta.Name, meta.Priority, meta.URL, meta.Website)
+}
+
+// FetchPages fetches the entries of paged list of the feed of URL from the first page, newest first, until a page is
+// empty, every entry of a page exists or maxPages is reached
+func FetchPages(ctx context.Context, url string, fetchPage func(ctx context.Context, pageNum int) ([]*models.Entry, error)) ([]*models.Entry, error) {
+	feedID, err := models.GetFeedIDForURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*models.Entry
+	for pageNum := 1; pageNum <= maxPages; pageNum++ {
+		page, err := fetchPage(ctx, pageNum)
+		if err != nil {
+			return nil, err
+		}
+		if len(page) == 0 {
+			break
+		}
+		entries = append(entries, page...)
+
+		if feedID == -1 {
+			continue
+		}
+		gUIDs := make(map[string]struct{}, len(page))
+		for _, entry := range page {
+			gUIDs[entry.GUID] = struct{}{}
+		}
+		pageGUIDs := make([]string, 0, len(gUIDs))
+		for gUID := range gUIDs {
+			pageGUIDs = append(pageGUIDs, gUID)
+		}
+		existingGUIDs, err := models.ExistingGUIDs(feedID, pageGUIDs)
+		if err != nil {
+			return nil, err
+		}
+		if len(existingGUIDs) == len(pageGUIDs) {
+			break
+		}
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,82 @@
+package feeds
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"reader/internal/pkg/fetch"
+)
+
+var (
+	client      *fetch.Client
+	clientMutex sync.RWMutex
+)
+
+func init() {
+	c, err := fetch.New(nil)
+	if err != nil {
+		panic(err)
+	}
+	client = c
+}
+
+// Client returns the shared HTTP client
+func Client() *fetch.Client {
+	clientMutex.RLock()
+	defer clientMutex.RUnlock()
+
+	return client
+}
+
+// Get gets the response body of URL with the shared HTTP client
+func Get(ctx context.Context, url string) ([]byte, error) {
+	resp, err := Client().Get(ctx, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// SetClient replaces the shared HTTP client
+func SetClient(c *fetch.Client) {
+	clientMutex.Lock()
+	defer clientMutex.Unlock()
+
+	client = c
+}
+
+// SetupClient setups the shared HTTP client from environment
+func SetupClient() error {
+	options := &fetch.Options{
+		Proxy:     os.Getenv("FETCH_PROXY"),
+		UserAgent: os.Getenv("FETCH_USER_AGENT"),
+	}
+
+	if v := os.Getenv("FETCH_MAX_BODY_SIZE"); v != "" {
+		size, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		options.MaxBodySize = size
+	}
+
+	if v := os.Getenv("FETCH_TIMEOUT"); v != "" {
+		sec, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		options.Timeout = time.Duration(sec) * time.Second
+	}
+
+	c, err := fetch.New(options)
+	if err != nil {
+		return err
+	}
+	SetClient(c)
+
+	return nil
+}
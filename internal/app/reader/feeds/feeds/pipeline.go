@@ -0,0 +1,135 @@
+package feeds
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"reader/internal/app/reader/models"
+	"reader/internal/app/reader/rules"
+	"reader/internal/app/reader/webhooks"
+	"reader/internal/pkg/fetch"
+)
+
+// Ingest fetches entries with fetcher and stores the new ones for feed, returns the new entry count
+func Ingest(ctx context.Context, feedID int64, f Fetcher) (int, error) {
+	meta := f.Meta()
+
+	log.WithFields(log.Fields{
+		"feed": meta.Name,
+	}).Info("Fetch")
+
+	entries, err := f.Fetch(ctx)
+	if err != nil {
+		if errors.Is(err, fetch.ErrNotModified) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	entries, err = newEntries(feedID, entries)
+	if err != nil {
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	completer, _ := f.(Completer)
+
+	count := 0
+	for _, entry := range entries {
+		if completer != nil {
+			if err := completer.Complete(ctx, entry); err != nil {
+				return count, err
+			}
+		}
+
+		entry.FeedID = feedID
+		if meta.DateOnly {
+			_, err = models.AddEntryWithDateCount(entry)
+		} else {
+			_, err = models.AddEntry(entry)
+		}
+		if err != nil {
+			return count, err
+		}
+		count++
+
+		// rules run first so that webhooks see their tags, and events and webhooks skip entries they delete
+		if err := rules.Apply(entry); err != nil {
+			log.WithFields(log.Fields{
+				"feed":  meta.Name,
+				"entry": entry.ID,
+				"error": err,
+			}).Error("Apply rules")
+		}
+		if err := models.PublishEntry(entry); err != nil {
+			log.WithFields(log.Fields{
+				"feed":  meta.Name,
+				"entry": entry.ID,
+				"error": err,
+			}).Error("Publish entry")
+		}
+		if err := webhooks.Enqueue(entry); err != nil {
+			log.WithFields(log.Fields{
+				"feed":  meta.Name,
+				"entry": entry.ID,
+				"error": err,
+			}).Error("Enqueue webhooks")
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"feed":  meta.Name,
+		"count": count,
+	}).Info("Ingest")
+
+	return count, nil
+}
+
+// newEntries drops duplicate entries and existing ones of feed, the rest are returned oldest first
+func newEntries(feedID int64, entries []*models.Entry) ([]*models.Entry, error) {
+	var deduplicateEntries []*models.Entry
+	var gUIDs []string
+	gUIDMap := make(map[string]struct{})
+	for _, entry := range entries {
+		if _, ok := gUIDMap[entry.GUID]; ok || entry.GUID == "" {
+			continue
+		}
+		gUIDMap[entry.GUID] = struct{}{}
+
+		deduplicateEntries = append(deduplicateEntries, entry)
+		gUIDs = append(gUIDs, entry.GUID)
+	}
+	if len(gUIDs) == 0 {
+		return nil, nil
+	}
+
+	existingGUIDs, err := models.ExistingGUIDs(feedID, gUIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	existingMap := make(map[string]struct{}, len(existingGUIDs))
+	for _, gUID := range existingGUIDs {
+		existingMap[gUID] = struct{}{}
+	}
+
+	var fresh []*models.Entry
+	for i := len(deduplicateEntries) - 1; i >= 0; i-- {
+		entry := deduplicateEntries[i]
+		if _, ok := existingMap[entry.GUID]; !ok {
+			fresh = append(fresh, entry)
+		}
+	}
+
+	// insert oldest first so that entry IDs follow publishing order
+	sort.SliceStable(fresh, func(i, j int) bool {
+		return fresh[i].Date.Before(fresh[j].Date)
+	})
+
+	return fresh, nil
+}
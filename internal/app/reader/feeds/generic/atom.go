@@ -0,0 +1,106 @@
+package generic
+
+import (
+	"bytes"
+	"html"
+	"strings"
+)
+
+const (
+	atomNamespace = "http://www.w3.org/2005/Atom"
+)
+
+type atomFeed struct {
+	Authors []*atomPerson `xml:"author"`
+	Entries []*atomEntry  `xml:"entry"`
+	Links   []*atomLink   `xml:"link"`
+	Title   atomText      `xml:"title"`
+}
+
+type atomEntry struct {
+	Authors   []*atomPerson `xml:"author"`
+	Content   *atomText     `xml:"content"`
+	ID        string        `xml:"id"`
+	Links     []*atomLink   `xml:"link"`
+	Published string        `xml:"published"`
+	Summary   *atomText     `xml:"summary"`
+	Title     atomText      `xml:"title"`
+	Updated   string        `xml:"updated"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type     string `xml:"type,attr"`
+	Text     string `xml:",chardata"`
+	InnerXML string `xml:",innerxml"`
+}
+
+// html returns the text construct as HTML
+func (t *atomText) html() string {
+	if t == nil {
+		return ""
+	}
+
+	switch t.Type {
+	case "html", "text/html":
+		return strings.TrimSpace(t.Text)
+	case "xhtml", "application/xhtml+xml":
+		return strings.TrimSpace(t.InnerXML)
+	default:
+		return html.EscapeString(strings.TrimSpace(t.Text))
+	}
+}
+
+func alternateLink(links []*atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+
+	return ""
+}
+
+func firstPersonName(persons []*atomPerson) string {
+	for _, person := range persons {
+		if name := strings.TrimSpace(person.Name); name != "" {
+			return name
+		}
+	}
+
+	return ""
+}
+
+func parseAtom(body []byte) (*Document, error) {
+	var f atomFeed
+	if err := newXMLDecoder(bytes.NewReader(body)).Decode(&f); err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		Title:   strings.TrimSpace(f.Title.Text),
+		Website: alternateLink(f.Links),
+	}
+
+	feedAuthor := firstPersonName(f.Authors)
+	for _, entry := range f.Entries {
+		doc.Items = append(doc.Items, &Item{
+			Author:  firstNonEmpty(firstPersonName(entry.Authors), feedAuthor),
+			Content: firstNonEmpty(entry.Content.html(), entry.Summary.html()),
+			Date:    parseDate(firstNonEmpty(entry.Published, entry.Updated)),
+			GUID:    strings.TrimSpace(entry.ID),
+			Link:    alternateLink(entry.Links),
+			Title:   strings.TrimSpace(entry.Title.Text),
+		})
+	}
+
+	return doc, nil
+}
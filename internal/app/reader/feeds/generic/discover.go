@@ -0,0 +1,151 @@
+package generic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"reader/internal/app/reader/feeds/feeds"
+)
+
+// ErrNoFeedFound is returned when neither the page nor its alternate links are feeds
+var ErrNoFeedFound = errors.New("no feed found")
+
+var feedContentTypes = map[string]struct{}{
+	"application/atom+xml":  {},
+	"application/feed+json": {},
+	"application/json":      {},
+	"application/rdf+xml":   {},
+	"application/rss+xml":   {},
+	"application/xml":       {},
+	"text/xml":              {},
+}
+
+// Discovery discovered feed
+type Discovery struct {
+	Title   string
+	URL     string
+	Website string
+}
+
+// Discover finds the feed of page URL, which is either a feed itself or
+// an HTML page announcing feeds with <link rel="alternate">
+func Discover(ctx context.Context, pageURL string) (*Discovery, error) {
+	resp, err := feeds.Client().Get(ctx, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := Parse(resp.Body, resp.URL)
+	if err == nil {
+		return newDiscovery(doc, resp.URL), nil
+	}
+	if !errors.Is(err, ErrUnknownFormat) {
+		return nil, err
+	}
+
+	for _, link := range alternateFeedLinks(resp.Body, resp.URL) {
+		resp, err := feeds.Client().Get(ctx, link, nil)
+		if err != nil {
+			continue
+		}
+		if doc, err := Parse(resp.Body, resp.URL); err == nil {
+			discovery := newDiscovery(doc, link)
+			if discovery.Website == "" {
+				discovery.Website = pageURL
+			}
+			return discovery, nil
+		}
+	}
+
+	return nil, ErrNoFeedFound
+}
+
+func newDiscovery(doc *Document, feedURL string) *Discovery {
+	title := doc.Title
+	if title == "" {
+		title = feedURL
+	}
+
+	return &Discovery{
+		Title:   title,
+		URL:     feedURL,
+		Website: doc.Website,
+	}
+}
+
+// alternateFeedLinks returns the absolute URLs of feed links announced by HTML page
+func alternateFeedLinks(body []byte, base string) []string {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil
+	}
+
+	tokenizer := html.NewTokenizer(bytes.NewReader(body))
+
+	var links []string
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return links
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tagName, hasAttr := tokenizer.TagName()
+			if !hasAttr {
+				continue
+			}
+
+			switch string(tagName) {
+			case "base":
+				attributes := tagAttributes(tokenizer)
+				if href, ok := attributes["href"]; ok {
+					if u, err := url.Parse(href); err == nil {
+						baseURL = baseURL.ResolveReference(u)
+					}
+				}
+			case "link":
+				attributes := tagAttributes(tokenizer)
+				if !hasToken(attributes["rel"], "alternate") {
+					continue
+				}
+				contentType := strings.ToLower(strings.TrimSpace(attributes["type"]))
+				if _, ok := feedContentTypes[contentType]; !ok {
+					continue
+				}
+				if href := strings.TrimSpace(attributes["href"]); href != "" {
+					links = append(links, resolveURL(baseURL, href))
+				}
+			}
+		case html.EndTagToken:
+			if tagName, _ := tokenizer.TagName(); string(tagName) == "head" {
+				return links
+			}
+		}
+	}
+}
+
+func hasToken(list, token string) bool {
+	for _, t := range strings.Fields(strings.ToLower(list)) {
+		if t == token {
+			return true
+		}
+	}
+
+	return false
+}
+
+func tagAttributes(tokenizer *html.Tokenizer) map[string]string {
+	attributes := make(map[string]string)
+	for {
+		key, value, more := tokenizer.TagAttr()
+		attributes[strings.ToLower(string(key))] = string(value)
+		if !more {
+			break
+		}
+	}
+
+	return attributes
+}
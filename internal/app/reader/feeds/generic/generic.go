@@ -0,0 +1,91 @@
+package generic
+
+import (
+	"context"
+	"time"
+
+	"reader/internal/app/reader/feeds/feeds"
+	"reader/internal/app/reader/models"
+	"reader/internal/pkg/fetch"
+	"reader/internal/pkg/utils"
+)
+
+const (
+	maxAuthorLength = 255
+	maxGUIDLength   = 760
+	maxLinkLength   = 1023
+	maxTitleLength  = 255
+)
+
+func (i *Item) parseToEntry(now time.Time) *models.Entry {
+	gUID := i.GUID
+	if len(gUID) > maxGUIDLength {
+		gUID = utils.Sha1(gUID)
+	}
+
+	date := i.Date
+	if date.IsZero() {
+		date = now
+	}
+
+	return &models.Entry{
+		Author:  utils.Truncate(i.Author, maxAuthorLength),
+		Content: i.Content,
+		Date:    date,
+		GUID:    gUID,
+		Link:    utils.Truncate(i.Link, maxLinkLength),
+		Title:   utils.Truncate(i.Title, maxTitleLength),
+	}
+}
+
+type fetcher struct {
+	feed *models.Feed
+}
+
+// New creates a fetcher for feed published as RSS, Atom or JSON Feed document
+func New(feed *models.Feed) feeds.Fetcher {
+	return &fetcher{feed: feed}
+}
+
+// Name returns the fetcher name
+func (f *fetcher) Name() string {
+	return "generic"
+}
+
+// Meta returns the feed metadata
+func (f *fetcher) Meta() *feeds.Meta {
+	return &feeds.Meta{
+		Name:     f.feed.Name,
+		Priority: f.feed.Priority,
+		URL:      f.feed.URL,
+		Website:  f.feed.Website,
+	}
+}
+
+// Fetch fetches and parses the feed document, conditional request validators of the feed are updated
+func (f *fetcher) Fetch(ctx context.Context) ([]*models.Entry, error) {
+	resp, err := feeds.Client().Get(ctx, f.feed.URL, &fetch.Validators{
+		ETag:         f.feed.ETag,
+		LastModified: f.feed.LastModified,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := Parse(resp.Body, resp.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	f.feed.ETag = utils.Truncate(resp.Validators.ETag, 255)
+	f.feed.LastModified = utils.Truncate(resp.Validators.LastModified, 63)
+
+	now := time.Now()
+
+	var entries []*models.Entry
+	for _, item := range doc.Items {
+		entries = append(entries, item.parseToEntry(now))
+	}
+
+	return entries, nil
+}
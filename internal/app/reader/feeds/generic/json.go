@@ -0,0 +1,110 @@
+package generic
+
+import (
+	"encoding/json"
+	"html"
+	"strings"
+)
+
+const (
+	jsonFeedVersionPrefix = "https://jsonfeed.org/version/"
+)
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+// jsonID item ID, which is a string by spec but published as a number by some feeds
+type jsonID string
+
+// UnmarshalJSON unmarshal for wrapper, dispatches `string` and `number`
+func (i *jsonID) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*i = jsonID(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*i = jsonID(n.String())
+	return nil
+}
+
+type jsonFeed struct {
+	Author      *jsonAuthor   `json:"author"` // version 1.0
+	Authors     []*jsonAuthor `json:"authors"`
+	HomePageURL string        `json:"home_page_url"`
+	Items       []*jsonItem   `json:"items"`
+	Title       string        `json:"title"`
+	Version     string        `json:"version"`
+}
+
+type jsonItem struct {
+	Author        *jsonAuthor   `json:"author"` // version 1.0
+	Authors       []*jsonAuthor `json:"authors"`
+	ContentHTML   string        `json:"content_html"`
+	ContentText   string        `json:"content_text"`
+	DateModified  string        `json:"date_modified"`
+	DatePublished string        `json:"date_published"`
+	ExternalURL   string        `json:"external_url"`
+	ID            jsonID        `json:"id"`
+	Summary       string        `json:"summary"`
+	Title         string        `json:"title"`
+	URL           string        `json:"url"`
+}
+
+func jsonAuthorName(author *jsonAuthor, authors []*jsonAuthor) string {
+	for _, a := range authors {
+		if name := strings.TrimSpace(a.Name); name != "" {
+			return name
+		}
+	}
+	if author != nil {
+		return strings.TrimSpace(author.Name)
+	}
+
+	return ""
+}
+
+func (j *jsonItem) parseToItem(feedAuthor string) *Item {
+	content := strings.TrimSpace(j.ContentHTML)
+	if content == "" {
+		if text := firstNonEmpty(j.ContentText, j.Summary); text != "" {
+			content = html.EscapeString(text)
+		}
+	}
+
+	return &Item{
+		Author:  firstNonEmpty(jsonAuthorName(j.Author, j.Authors), feedAuthor),
+		Content: content,
+		Date:    parseDate(firstNonEmpty(j.DatePublished, j.DateModified)),
+		GUID:    strings.TrimSpace(string(j.ID)),
+		Link:    firstNonEmpty(j.URL, j.ExternalURL),
+		Title:   strings.TrimSpace(j.Title),
+	}
+}
+
+func parseJSONFeed(body []byte) (*Document, error) {
+	var f jsonFeed
+	if err := json.Unmarshal(body, &f); err != nil {
+		return nil, ErrUnknownFormat
+	}
+	if !strings.HasPrefix(f.Version, jsonFeedVersionPrefix) {
+		return nil, ErrUnknownFormat
+	}
+
+	doc := &Document{
+		Title:   strings.TrimSpace(f.Title),
+		Website: strings.TrimSpace(f.HomePageURL),
+	}
+
+	feedAuthor := jsonAuthorName(f.Author, f.Authors)
+	for _, item := range f.Items {
+		doc.Items = append(doc.Items, item.parseToItem(feedAuthor))
+	}
+
+	return doc, nil
+}
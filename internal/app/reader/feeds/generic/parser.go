@@ -0,0 +1,170 @@
+package generic
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// ErrUnknownFormat is returned when the document is not a supported feed format
+var ErrUnknownFormat = errors.New("unknown feed format")
+
+// Document parsed feed document
+type Document struct {
+	Items   []*Item
+	Title   string
+	Website string
+}
+
+// Item parsed feed item
+type Item struct {
+	Author  string
+	Content string
+	Date    time.Time // zero if the item is undated
+	GUID    string
+	Link    string
+	Title   string
+}
+
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339Nano,
+	time.RFC3339,
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC850,
+	time.ANSIC,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"Mon, 2 Jan 2006 15:04 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// Parse parses RSS 2.0, RSS 1.0 (RDF), Atom 1.0 and JSON Feed documents,
+// relative links are resolved against base
+func Parse(body []byte, base string) (*Document, error) {
+	body = bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, ErrUnknownFormat
+	}
+
+	var doc *Document
+	var err error
+
+	switch body[0] {
+	case '{':
+		doc, err = parseJSONFeed(body)
+	case '<':
+		doc, err = parseXML(body)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		baseURL = nil
+	}
+
+	doc.Website = resolveURL(baseURL, doc.Website)
+	for _, item := range doc.Items {
+		item.Link = resolveURL(baseURL, item.Link)
+		if item.GUID == "" {
+			item.GUID = item.Link
+		}
+	}
+
+	return doc, nil
+}
+
+func newXMLDecoder(r io.Reader) *xml.Decoder {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	return decoder
+}
+
+func parseDate(s string) time.Time {
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		return time.Time{}
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func parseXML(body []byte) (*Document, error) {
+	decoder := newXMLDecoder(bytes.NewReader(body))
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, ErrUnknownFormat
+			}
+			return nil, err
+		}
+
+		root, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case root.Name.Local == "rss", root.Name.Local == "RDF":
+			return parseRSS(body)
+		case root.Name.Local == "feed" && root.Name.Space == atomNamespace:
+			return parseAtom(body)
+		default:
+			return nil, ErrUnknownFormat
+		}
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if base == nil || ref == "" {
+		return ref
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+
+	return base.ResolveReference(u).String()
+}
@@ -0,0 +1,171 @@
+package generic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRSS2(t *testing.T) {
+	body := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:atom="http://www.w3.org/2005/Atom">
+<channel>
+	<title>Example</title>
+	<link>https://example.com/</link>
+	<atom:link href="https://example.com/rss" rel="self" type="application/rss+xml" />
+	<item>
+		<title>First &amp; foremost</title>
+		<link>/posts/1</link>
+		<guid isPermaLink="false">post-1</guid>
+		<dc:creator>Alice</dc:creator>
+		<pubDate>Mon, 04 Jul 2022 08:30:00 +0800</pubDate>
+		<description>Summary</description>
+		<content:encoded><![CDATA[<p>Full text</p>]]></content:encoded>
+	</item>
+	<item>
+		<title>Second</title>
+		<link>https://example.com/posts/2</link>
+	</item>
+</channel>
+</rss>`
+
+	doc, err := Parse([]byte(body), "https://example.com/rss")
+	assert.Nil(t, err)
+	assert.Equal(t, "Example", doc.Title)
+	assert.Equal(t, "https://example.com/", doc.Website)
+	assert.Len(t, doc.Items, 2)
+
+	item := doc.Items[0]
+	assert.Equal(t, "First & foremost", item.Title)
+	assert.Equal(t, "https://example.com/posts/1", item.Link)
+	assert.Equal(t, "post-1", item.GUID)
+	assert.Equal(t, "Alice", item.Author)
+	assert.Equal(t, "<p>Full text</p>", item.Content)
+	assert.True(t, item.Date.Equal(time.Date(2022, 7, 4, 0, 30, 0, 0, time.UTC)))
+
+	item = doc.Items[1]
+	assert.Equal(t, "https://example.com/posts/2", item.GUID)
+	assert.True(t, item.Date.IsZero())
+}
+
+func TestParseRSS1(t *testing.T) {
+	body := `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+	<channel rdf:about="https://example.com/">
+		<title>RDF Example</title>
+		<link>https://example.com/</link>
+	</channel>
+	<item rdf:about="https://example.com/a">
+		<title>A</title>
+		<link>https://example.com/a</link>
+		<dc:date>2022-07-04T08:30:00+08:00</dc:date>
+		<description>Body</description>
+	</item>
+</rdf:RDF>`
+
+	doc, err := Parse([]byte(body), "https://example.com/rdf")
+	assert.Nil(t, err)
+	assert.Equal(t, "RDF Example", doc.Title)
+	assert.Len(t, doc.Items, 1)
+	assert.Equal(t, "https://example.com/a", doc.Items[0].GUID)
+	assert.Equal(t, "Body", doc.Items[0].Content)
+	assert.False(t, doc.Items[0].Date.IsZero())
+}
+
+func TestParseAtom(t *testing.T) {
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<title>Atom Example</title>
+	<link href="https://example.com/" />
+	<link href="https://example.com/atom" rel="self" />
+	<author><name>Bob</name></author>
+	<entry>
+		<title>Entry</title>
+		<link href="entries/1" rel="alternate" />
+		<id>urn:uuid:1</id>
+		<updated>2022-07-04T00:30:00Z</updated>
+		<summary>a &lt; b</summary>
+	</entry>
+	<entry>
+		<title>XHTML</title>
+		<id>urn:uuid:2</id>
+		<published>2022-07-05T00:30:00Z</published>
+		<content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>Hi</p></div></content>
+	</entry>
+</feed>`
+
+	doc, err := Parse([]byte(body), "https://example.com/atom")
+	assert.Nil(t, err)
+	assert.Equal(t, "Atom Example", doc.Title)
+	assert.Equal(t, "https://example.com/", doc.Website)
+	assert.Len(t, doc.Items, 2)
+
+	item := doc.Items[0]
+	assert.Equal(t, "urn:uuid:1", item.GUID)
+	assert.Equal(t, "https://example.com/entries/1", item.Link)
+	assert.Equal(t, "Bob", item.Author)
+	assert.Equal(t, "a &lt; b", item.Content)
+	assert.True(t, item.Date.Equal(time.Date(2022, 7, 4, 0, 30, 0, 0, time.UTC)))
+
+	assert.Contains(t, doc.Items[1].Content, "<p>Hi</p>")
+}
+
+func TestParseJSONFeed(t *testing.T) {
+	body := `{
+		"version": "https://jsonfeed.org/version/1.1",
+		"title": "JSON Example",
+		"home_page_url": "https://example.com/",
+		"authors": [{"name": "Carol"}],
+		"items": [
+			{
+				"id": 1,
+				"url": "https://example.com/1",
+				"title": "One",
+				"content_text": "1 < 2",
+				"date_published": "2022-07-04T00:30:00Z"
+			}
+		]
+	}`
+
+	doc, err := Parse([]byte(body), "https://example.com/feed.json")
+	assert.Nil(t, err)
+	assert.Equal(t, "JSON Example", doc.Title)
+	assert.Len(t, doc.Items, 1)
+
+	item := doc.Items[0]
+	assert.Equal(t, "1", item.GUID)
+	assert.Equal(t, "Carol", item.Author)
+	assert.Equal(t, "1 &lt; 2", item.Content)
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	for _, body := range []string{
+		``,
+		`<!DOCTYPE html><html><body>news</body></html>`,
+		`{"retcode": 0, "data": {"list": []}}`,
+		`plain text`,
+	} {
+		_, err := Parse([]byte(body), "https://example.com/")
+		assert.ErrorIs(t, err, ErrUnknownFormat)
+	}
+}
+
+func TestAlternateFeedLinks(t *testing.T) {
+	body := `<!DOCTYPE html>
+<html>
+<head>
+	<link rel="stylesheet" href="/style.css">
+	<link rel="alternate" type="application/rss+xml" title="RSS" href="/rss.xml">
+	<link rel="alternate" type="application/atom+xml" href="https://example.org/atom.xml" />
+	<link rel="alternate" hreflang="en" href="/en/">
+</head>
+<body><link rel="alternate" type="application/rss+xml" href="/ignored.xml"></body>
+</html>`
+
+	links := alternateFeedLinks([]byte(body), "https://example.com/blog/")
+	assert.Equal(t, []string{
+		"https://example.com/rss.xml",
+		"https://example.org/atom.xml",
+	}, links)
+}
@@ -0,0 +1,65 @@
+package generic
+
+import (
+	"bytes"
+)
+
+// rssDocument covers both RSS 2.0 (items inside channel) and RSS 1.0 (items beside channel)
+type rssDocument struct {
+	Channel rssChannel `xml:"channel"`
+	Items   []*rssItem `xml:"item"`
+}
+
+type rssChannel struct {
+	Items []*rssItem `xml:"item"`
+	Links []string   `xml:"link"`
+	Title string     `xml:"title"`
+}
+
+type rssItem struct {
+	About       string   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Author      string   `xml:"author"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Description string   `xml:"description"`
+	Encoded     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	GUID        string   `xml:"guid"`
+	Links       []string `xml:"link"`
+	PubDate     string   `xml:"pubDate"`
+	Title       string   `xml:"title"`
+}
+
+func (r *rssItem) parseToItem() *Item {
+	link := firstNonEmpty(r.Links...)
+
+	return &Item{
+		Author:  firstNonEmpty(r.Creator, r.Author),
+		Content: firstNonEmpty(r.Encoded, r.Description),
+		Date:    parseDate(firstNonEmpty(r.PubDate, r.Date)),
+		GUID:    firstNonEmpty(r.GUID, r.About, link),
+		Link:    link,
+		Title:   firstNonEmpty(r.Title),
+	}
+}
+
+func parseRSS(body []byte) (*Document, error) {
+	var r rssDocument
+	if err := newXMLDecoder(bytes.NewReader(body)).Decode(&r); err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		Title:   firstNonEmpty(r.Channel.Title),
+		Website: firstNonEmpty(r.Channel.Links...),
+	}
+
+	items := r.Channel.Items
+	if len(items) == 0 {
+		items = r.Items
+	}
+	for _, item := range items {
+		doc.Items = append(doc.Items, item.parseToItem())
+	}
+
+	return doc, nil
+}
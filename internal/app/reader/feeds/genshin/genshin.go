@@ -1,17 +1,14 @@
 package genshin
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
-	log "github.com/sirupsen/logrus"
-
 	"reader/internal/app/reader/feeds/feeds"
 	"reader/internal/app/reader/models"
 	"reader/internal/pkg/utils"
@@ -23,6 +20,7 @@ const (
 	feedName     = "Genshin Impact"
 	feedPriority = int8(10)
 	feedWebsite  = "https://ys.mihoyo.com/main/news"
+	pageSize     = 20
 	timeFormat   = "2006-01-02 15:04:05"
 )
 
@@ -65,22 +63,13 @@ type contentItem struct {
 	URL          string       `json:"url"`
 }
 
-func (c *contentItem) parseToEntry(feedID int64) (*models.Entry, error) {
+func (c *contentItem) parseToEntry() (*models.Entry, error) {
 	entry := &models.Entry{
-		Author:   c.Author,
-		Favorite: false,
-		GUID:     fmt.Sprintf("https://ys.mihoyo.com/main/news/%s", c.ContentID),
-		Link:     fmt.Sprintf("https://ys.mihoyo.com/main/news/detail/%s", c.ContentID),
-		Read:     false,
-		Title:    c.Title,
-		FeedID:   feedID,
-	}
-
-	content, err := fetchContent(entry.Link)
-	if err != nil {
-		return nil, err
+		Author: c.Author,
+		GUID:   fmt.Sprintf("https://ys.mihoyo.com/main/news/%s", c.ContentID),
+		Link:   fmt.Sprintf("https://ys.mihoyo.com/main/news/detail/%s", c.ContentID),
+		Title:  c.Title,
 	}
-	entry.Content = content
 
 	startTime, err := time.ParseInLocation(timeFormat, c.StartTime, utils.Beijing)
 	if err != nil {
@@ -102,96 +91,64 @@ type contentResponse struct {
 	ReturnCode int          `json:"retcode"`
 }
 
-// Fetch fetches Genshin Impact official news articles
-func Fetch() error {
-	categoryID, err := feeds.SetupCategory(feeds.GamesCategoryName)
-	if err != nil {
-		return err
-	}
+type fetcher struct{}
 
-	feedID, err := feeds.SetupFeed(categoryID, feedName, feedPriority, contentURL, feedWebsite)
-	if err != nil {
-		return err
-	}
-
-	pageSize := 5
-	pageNum := 1
+func init() {
+	feeds.Register(&fetcher{})
+}
 
-	for {
-		log.WithFields(log.Fields{
-			"feed": feedName,
-			"page": pageNum,
-			"size": pageSize,
-		}).Info("Fetch")
+// Name returns the fetcher name
+func (f *fetcher) Name() string {
+	return "genshin"
+}
 
-		items, err := fetchContentList(pageSize, pageNum)
-		if err != nil {
-			return err
-		}
-		if len(items) == 0 {
-			return nil
-		}
+// Meta returns the feed metadata
+func (f *fetcher) Meta() *feeds.Meta {
+	return &feeds.Meta{
+		Category: feeds.GamesCategoryName,
+		Name:     feedName,
+		Priority: feedPriority,
+		URL:      contentURL,
+		Website:  feedWebsite,
+	}
+}
 
-		var entries []*models.Entry
-		var gUIDs []string
-		for _, item := range items {
-			entry, err := item.parseToEntry(feedID)
-			if err != nil {
-				return err
-			}
+// Fetch fetches Genshin Impact official news articles, page by page until the known ones
+func (f *fetcher) Fetch(ctx context.Context) ([]*models.Entry, error) {
+	return feeds.FetchPages(ctx, contentURL, fetchPage)
+}
 
-			entries = append(entries, entry)
-			gUIDs = append(gUIDs, entry.GUID)
-		}
+func fetchPage(ctx context.Context, pageNum int) ([]*models.Entry, error) {
+	items, err := fetchContentList(ctx, pageSize, pageNum)
+	if err != nil {
+		return nil, err
+	}
 
-		existingGUIDs, err := models.ExistingGUIDs(gUIDs)
+	var entries []*models.Entry
+	for _, item := range items {
+		entry, err := item.parseToEntry()
 		if err != nil {
-			return err
-		}
-
-		if len(existingGUIDs) == len(gUIDs) {
-			break
-		}
-
-		gUIDMap := make(map[string]struct{}, len(existingGUIDs))
-		for _, gUID := range existingGUIDs {
-			gUIDMap[gUID] = struct{}{}
+			return nil, err
 		}
-
-		hasDuplicate := false
-		pureDuplicate := true // all duplicate entries are at the last part
-
-		for _, entry := range entries {
-			if _, ok := gUIDMap[entry.GUID]; ok {
-				hasDuplicate = true
-				continue
-			}
-			if _, err = models.AddEntry(entry); err != nil {
-				return err
-			}
-			if hasDuplicate {
-				pureDuplicate = false
-			}
-		}
-
-		if hasDuplicate && pureDuplicate {
-			break
-		}
-
-		pageNum++
+		entries = append(entries, entry)
 	}
 
-	return nil
+	return entries, nil
 }
 
-func fetchContent(url string) (string, error) {
-	resp, err := http.Get(url)
+// Complete fetches content of the article
+func (f *fetcher) Complete(ctx context.Context, entry *models.Entry) error {
+	content, err := fetchContent(ctx, entry.Link)
 	if err != nil {
-		return "", err
+		return err
 	}
-	defer resp.Body.Close()
+	entry.Content = content
 
-	body, err := io.ReadAll(resp.Body)
+	return nil
+}
+
+func fetchContent(ctx context.Context, url string) (string, error) {
+	body, err := feeds.Get(ctx, url)
 	if err != nil {
 		return "", err
 	}
@@ -221,19 +178,13 @@ func fetchContent(url string) (string, error) {
 	return content, nil
 }
 
-func fetchContentList(pageSize, pageNum int) ([]*contentItem, error) {
+func fetchContentList(ctx context.Context, pageSize, pageNum int) ([]*contentItem, error) {
 	v := url.Values{}
 	v.Set("pageSize", fmt.Sprintf("%d", pageSize))
 	v.Set("pageNum", fmt.Sprintf("%d", pageNum))
 	v.Set("channelId", fmt.Sprintf("%d", channelID))
 
-	resp, err := http.Get(fmt.Sprintf("%s?%s", contentURL, v.Encode()))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	body, err := feeds.Get(ctx, fmt.Sprintf("%s?%s", contentURL, v.Encode()))
 	if err != nil {
 		return nil, err
 	}
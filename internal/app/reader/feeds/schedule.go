@@ -0,0 +1,86 @@
+package feeds
+
+import (
+	"math/rand"
+	"time"
+
+	"reader/internal/app/reader/models"
+	"reader/internal/pkg/utils"
+)
+
+const (
+	defaultInterval = 600 * time.Second
+	maxBackoff      = 24 * time.Hour
+	maxInterval     = 12 * time.Hour
+	minInterval     = 5 * time.Minute
+	jitterRatio     = 0.1
+)
+
+// checkInterval returns the check interval of feed within bounds
+func checkInterval(feed *models.Feed) time.Duration {
+	interval := time.Duration(feed.CheckInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	return clampInterval(interval)
+}
+
+func clampInterval(interval time.Duration) time.Duration {
+	if interval < minInterval {
+		return minInterval
+	}
+	if interval > maxInterval {
+		return maxInterval
+	}
+
+	return interval
+}
+
+// jitter spreads d randomly by jitterRatio in both directions
+func jitter(d time.Duration) time.Duration {
+	delta := time.Duration(float64(d) * jitterRatio)
+	if delta <= 0 {
+		return d
+	}
+
+	return d - delta + time.Duration(rand.Int63n(int64(2*delta)))
+}
+
+// reschedule updates the schedule state of feed for a check result at now,
+// busy feeds are checked more often, quiet ones less, failing ones back off exponentially
+func reschedule(feed *models.Feed, count int, err error, now time.Time) {
+	interval := checkInterval(feed)
+
+	if err != nil {
+		feed.FailureCount++
+		feed.LastError = utils.Truncate(err.Error(), 1023)
+
+		// validators may be newer than what was stored, force a full fetch next time
+		feed.ETag = ""
+		feed.LastModified = ""
+
+		backoff := interval
+		for i := int32(1); i < feed.FailureCount && backoff < maxBackoff; i++ {
+			backoff *= 2
+		}
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+
+		feed.NextCheckAt = now.Add(jitter(backoff))
+		return
+	}
+
+	if count > 0 {
+		interval = clampInterval(interval / 2)
+	} else {
+		interval = clampInterval(interval * 3 / 2)
+	}
+
+	feed.CheckInterval = int32(interval / time.Second)
+	feed.FailureCount = 0
+	feed.LastError = ""
+	feed.LastSuccessAt = &now
+	feed.NextCheckAt = now.Add(jitter(interval))
+}
@@ -0,0 +1,67 @@
+package feeds
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"reader/internal/app/reader/models"
+)
+
+func within(t *testing.T, expected, actual time.Duration) {
+	t.Helper()
+
+	delta := time.Duration(float64(expected) * jitterRatio)
+	assert.GreaterOrEqual(t, actual, expected-delta)
+	assert.LessOrEqual(t, actual, expected+delta)
+}
+
+func TestReschedule(t *testing.T) {
+	now := time.Now()
+
+	feed := &models.Feed{CheckInterval: 600}
+	reschedule(feed, 3, nil, now)
+	assert.Equal(t, int32(300), feed.CheckInterval)
+	assert.Equal(t, &now, feed.LastSuccessAt)
+	within(t, 300*time.Second, feed.NextCheckAt.Sub(now))
+
+	reschedule(feed, 3, nil, now)
+	assert.Equal(t, int32(minInterval/time.Second), feed.CheckInterval)
+
+	feed = &models.Feed{CheckInterval: 600}
+	reschedule(feed, 0, nil, now)
+	assert.Equal(t, int32(900), feed.CheckInterval)
+
+	feed = &models.Feed{CheckInterval: int32(maxInterval / time.Second)}
+	reschedule(feed, 0, nil, now)
+	assert.Equal(t, int32(maxInterval/time.Second), feed.CheckInterval)
+}
+
+func TestRescheduleBackoff(t *testing.T) {
+	now := time.Now()
+	err := errors.New("timeout")
+
+	feed := &models.Feed{CheckInterval: 600}
+	for i, expected := range []time.Duration{
+		10 * time.Minute,
+		20 * time.Minute,
+		40 * time.Minute,
+		80 * time.Minute,
+	} {
+		reschedule(feed, 0, err, now)
+		assert.Equal(t, int32(i+1), feed.FailureCount)
+		assert.Equal(t, "timeout", feed.LastError)
+		assert.Equal(t, int32(600), feed.CheckInterval)
+		within(t, expected, feed.NextCheckAt.Sub(now))
+	}
+
+	feed.FailureCount = 30
+	reschedule(feed, 0, err, now)
+	within(t, maxBackoff, feed.NextCheckAt.Sub(now))
+
+	reschedule(feed, 1, nil, now)
+	assert.Equal(t, int32(0), feed.FailureCount)
+	assert.Equal(t, "", feed.LastError)
+}
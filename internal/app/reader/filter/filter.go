@@ -0,0 +1,284 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+
+	"reader/internal/app/reader"
+	"reader/internal/app/reader/models"
+)
+
+// ErrUnresolved a feed, category or tag of filter does not exist for user
+var ErrUnresolved = errors.New("unresolved")
+
+// Filter parsed query of saved search, terms are separated by spaces and all must match:
+//
+//	feed:<id or title>      entries of subscribed feed
+//	category:<name>         entries of feeds in category
+//	tag:<name>              entries with tag
+//	is:read|unread|starred|unstarred
+//	after:<date or age>     published after date (2006-01-02 or RFC 3339) or age (30m, 12h, 7d, 2w) ago
+//	before:<date or age>    published before date or age ago
+//
+// values with spaces are quoted, e.g. category:"Genshin Impact", other terms are full-text search
+type Filter struct {
+	After    *Bound
+	Before   *Bound
+	Category string
+	Feed     string
+	State    reader.State
+	Tag      string
+	Text     string
+}
+
+// Bound date bound of filter, absolute or relative to now
+type Bound struct {
+	At  time.Time
+	Ago time.Duration
+}
+
+// Time returns the time of bound at now
+func (b *Bound) Time(now time.Time) time.Time {
+	if b.Ago > 0 {
+		return now.Add(-b.Ago)
+	}
+
+	return b.At
+}
+
+// Compiled filter compiled for user
+type Compiled struct {
+	CategoryID int64 // 0 if not restricted to category
+	FeedID     int64 // 0 if not restricted to feed
+	Scopes     []func(*gorm.DB) *gorm.DB
+}
+
+// tokenize splits query by spaces outside double quotes, quotes are kept
+func tokenize(query string) ([]string, error) {
+	var tokens []string
+	var b strings.Builder
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			b.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if b.Len() > 0 {
+				tokens = append(tokens, b.String())
+				b.Reset()
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, errors.New("unterminated quote")
+	}
+	if b.Len() > 0 {
+		tokens = append(tokens, b.String())
+	}
+
+	return tokens, nil
+}
+
+// parseAge parses age like 30m, 12h, 7d or 2w
+func parseAge(v string) (time.Duration, bool) {
+	if len(v) < 2 {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(v[:len(v)-1])
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+
+	var unit time.Duration
+	switch v[len(v)-1] {
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, false
+	}
+
+	return time.Duration(n) * unit, true
+}
+
+func parseBound(v string) (*Bound, error) {
+	if ago, ok := parseAge(v); ok {
+		return &Bound{Ago: ago}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil {
+		return &Bound{At: t}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &Bound{At: t}, nil
+	}
+
+	return nil, fmt.Errorf("invalid date %q", v)
+}
+
+// Parse parses query of saved search
+func Parse(query string) (*Filter, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Filter{State: reader.StateAll | reader.StateFavorite | reader.StateNotFavorite}
+	var text []string
+	set := func(field *string, key, value string) error {
+		if *field != "" {
+			return fmt.Errorf("duplicate %s", key)
+		}
+		*field = value
+		return nil
+	}
+
+	for _, token := range tokens {
+		key, value, ok := strings.Cut(token, ":")
+		key = strings.ToLower(key)
+		if !ok || strings.HasPrefix(key, `"`) {
+			text = append(text, token)
+			continue
+		}
+		value = strings.TrimSpace(strings.Trim(value, `"`))
+
+		switch key {
+		case "feed", "category", "tag", "is", "after", "before":
+			if value == "" {
+				return nil, fmt.Errorf("empty %s", key)
+			}
+		}
+
+		switch key {
+		case "feed":
+			err = set(&f.Feed, key, value)
+		case "category":
+			err = set(&f.Category, key, value)
+		case "tag":
+			err = set(&f.Tag, key, value)
+		case "is":
+			switch strings.ToLower(value) {
+			case "read":
+				f.State &^= reader.StateNotRead
+			case "unread":
+				f.State &^= reader.StateRead
+			case "starred":
+				f.State &^= reader.StateNotFavorite
+			case "unstarred":
+				f.State &^= reader.StateFavorite
+			default:
+				err = fmt.Errorf("invalid state %q", value)
+			}
+		case "after", "before":
+			bound := &f.After
+			if key == "before" {
+				bound = &f.Before
+			}
+			if *bound != nil {
+				err = fmt.Errorf("duplicate %s", key)
+			} else {
+				*bound, err = parseBound(value)
+			}
+		default:
+			text = append(text, token)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	f.Text = strings.Join(text, " ")
+
+	return f, nil
+}
+
+// Compile compiles filter to query scopes of user at now, they apply after models.UserScope,
+// ErrUnresolved is returned if a feed, category or tag does not exist
+func (f *Filter) Compile(userID int64, now time.Time) (*Compiled, error) {
+	compiled := &Compiled{}
+	restricted := false
+
+	if f.Feed != "" {
+		feedID, err := resolveFeed(userID, f.Feed)
+		if err != nil {
+			return nil, err
+		}
+		compiled.FeedID = feedID
+		compiled.Scopes = append(compiled.Scopes, models.FeedScope(feedID))
+		restricted = true
+	}
+	if f.Category != "" {
+		category, err := models.GetCategoryForName(userID, html.EscapeString(f.Category))
+		if err != nil {
+			return nil, err
+		}
+		if category == nil {
+			return nil, fmt.Errorf("category %q: %w", f.Category, ErrUnresolved)
+		}
+		compiled.CategoryID = category.ID
+		compiled.Scopes = append(compiled.Scopes, models.CategoryScope(category.ID))
+		restricted = true
+	}
+	if f.Tag != "" {
+		tagID, err := models.GetTagIDForName(userID, html.EscapeString(f.Tag))
+		if err != nil {
+			return nil, err
+		}
+		if tagID == -1 {
+			return nil, fmt.Errorf("tag %q: %w", f.Tag, ErrUnresolved)
+		}
+		compiled.Scopes = append(compiled.Scopes, models.TagScope(tagID))
+		restricted = true
+	}
+	if !restricted {
+		compiled.Scopes = append(compiled.Scopes, models.AllScope)
+	}
+
+	compiled.Scopes = append(compiled.Scopes, models.StateScope(f.State))
+	if f.After != nil {
+		compiled.Scopes = append(compiled.Scopes, models.StartTimeScope(f.After.Time(now)))
+	}
+	if f.Before != nil {
+		compiled.Scopes = append(compiled.Scopes, models.StopTimeScope(f.Before.Time(now)))
+	}
+	if f.Text != "" {
+		compiled.Scopes = append(compiled.Scopes, models.SearchScope(f.Text))
+	}
+
+	return compiled, nil
+}
+
+// resolveFeed resolves subscribed feed of user by ID or title, titles match case-insensitively
+func resolveFeed(userID int64, feed string) (int64, error) {
+	names, err := models.GetFeedAndCategoryNames(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if id, err := strconv.ParseInt(feed, 10, 64); err == nil {
+		if _, ok := names[id]; ok {
+			return id, nil
+		}
+	}
+	for id, name := range names {
+		if strings.EqualFold(html.UnescapeString(name.FeedName), feed) {
+			return id, nil
+		}
+	}
+
+	return 0, fmt.Errorf("feed %q: %w", feed, ErrUnresolved)
+}
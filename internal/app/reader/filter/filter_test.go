@@ -0,0 +1,45 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"reader/internal/app/reader"
+)
+
+func TestParse(t *testing.T) {
+	f, err := Parse(`category:"Genshin Impact" is:unread after:7d maintenance "server down"`)
+	assert.NoError(t, err)
+	assert.Equal(t, "Genshin Impact", f.Category)
+	assert.Equal(t, reader.State(reader.StateNotRead|reader.StateFavorite|reader.StateNotFavorite), f.State)
+	assert.Equal(t, 7*24*time.Hour, f.After.Ago)
+	assert.Nil(t, f.Before)
+	assert.Equal(t, `maintenance "server down"`, f.Text)
+
+	f, err = Parse("feed:12 tag:later is:starred before:2022-08-24 url:x")
+	assert.NoError(t, err)
+	assert.Equal(t, "12", f.Feed)
+	assert.Equal(t, "later", f.Tag)
+	assert.Equal(t, reader.State(reader.StateAll|reader.StateFavorite), f.State)
+	assert.Equal(t, time.Date(2022, 8, 24, 0, 0, 0, 0, time.Local), f.Before.Time(time.Now()))
+	assert.Equal(t, "url:x", f.Text)
+
+	now := time.Date(2022, 8, 24, 12, 0, 0, 0, time.UTC)
+	f, err = Parse("after:12h")
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(-12*time.Hour), f.After.Time(now))
+
+	for _, query := range []string{
+		`category:"Games`,
+		"is:pinned",
+		"after:yesterday",
+		"tag:a tag:b",
+		"before:1d before:2d",
+		"feed:",
+	} {
+		_, err := Parse(query)
+		assert.Error(t, err, query)
+	}
+}
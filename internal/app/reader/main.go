@@ -60,6 +60,21 @@ type StreamContentItem struct {
 	Title         string                        `json:"title"`
 }
 
+// StreamContents stream contents
+type StreamContents struct {
+	ID           string               `json:"id"`
+	Continuation string               `json:"continuation,omitempty"`
+	Items        []*StreamContentItem `json:"items"`
+	Title        string               `json:"title,omitempty"`
+	Updated      int64                `json:"updated"`
+}
+
+// SearchItems search results
+type SearchItems struct {
+	Results      []*StreamIDItem `json:"results"`
+	Continuation int64           `json:"continuation,omitempty"`
+}
+
 // StreamIDItem stream item
 type StreamIDItem struct {
 	ID string `json:"id"`
@@ -77,7 +92,8 @@ type StreamParams struct {
 	Count        int
 	Exclude      string
 	Filter       string
-	Order        bool // true for ASC, false for DESC
+	Order        bool   // true for ASC, false for DESC
+	Query        string // full-text search, empty for all
 	StartTime    int64
 	StopTime     int64
 }
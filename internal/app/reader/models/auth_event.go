@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"reader/internal/pkg/utils"
+)
+
+// auth event types
+const (
+	AuthEventLogin        = "login"
+	AuthEventLoginFailure = "login_failure"
+	AuthEventLoginLocked  = "login_locked"
+	AuthEventTokenIssue   = "token_issue"
+	AuthEventTokenRevoke  = "token_revoke"
+	AuthEventTokenUse     = "token_use"
+)
+
+// AuthEvent audit record of login and token usage
+type AuthEvent struct {
+	ID int64
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone;index:idx_auth_events_email_created;index:idx_auth_events_ip_created"`
+	Email     string    `gorm:"type:varchar(255);not null;default:'';index:idx_auth_events_email_created,priority:1"` // as submitted on login
+	Event     string    `gorm:"type:varchar(31);not null;index"`
+	IP        string    `gorm:"type:varchar(63);not null;default:'';index:idx_auth_events_ip_created,priority:1"`
+	UserAgent string    `gorm:"type:varchar(255);not null;default:''"`
+
+	AuthTokenID *int64 // kept after token is revoked
+	UserID      *int64 `gorm:"index"`
+}
+
+// AddAuthEvent records auth event
+func AddAuthEvent(event *AuthEvent) error {
+	event.Email = utils.Truncate(event.Email, 255)
+	event.IP = utils.Truncate(event.IP, 63)
+	event.UserAgent = utils.Truncate(event.UserAgent, 255)
+	if res := db.Create(&event); res.Error != nil {
+		return res.Error
+	}
+
+	return nil
+}
+
+// CountLoginFailuresForEmail counts failed logins for email since given time and the last successful login
+func CountLoginFailuresForEmail(email string, since time.Time) (int64, error) {
+	lastLogin := db.Model(&AuthEvent{}).
+		Select("COALESCE(MAX(created_at), '-infinity')").
+		Where("event = ?", AuthEventLogin).
+		Where("email = ?", email)
+
+	var count int64
+	if res := db.Model(&AuthEvent{}).
+		Where("event = ?", AuthEventLoginFailure).
+		Where("email = ?", email).
+		Where("created_at > ?", since).
+		Where("created_at > (?)", lastLogin).
+		Count(&count); res.Error != nil {
+		return 0, res.Error
+	}
+
+	return count, nil
+}
+
+// CountLoginFailuresForIP counts failed logins from IP since given time
+func CountLoginFailuresForIP(ip string, since time.Time) (int64, error) {
+	var count int64
+	if res := db.Model(&AuthEvent{}).
+		Where("event = ?", AuthEventLoginFailure).
+		Where("ip = ?", ip).
+		Where("created_at > ?", since).
+		Count(&count); res.Error != nil {
+		return 0, res.Error
+	}
+
+	return count, nil
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"reader/internal/pkg/utils"
+)
+
+const (
+	authTokenBytes = 32
+)
+
+// auth token scopes
+const (
+	AuthTokenScopeFever = "fever" // Fever API key, accepted by the Fever API only
+	AuthTokenScopeFull  = "full"  // accepted by all APIs except Fever
+)
+
+// AuthToken API token of user, only the hash of token is stored
+type AuthToken struct {
+	ID int64
+
+	CreatedAt  time.Time  `gorm:"type:timestamp with time zone"`
+	ExpiresAt  *time.Time `gorm:"type:timestamp with time zone"` // never expires if nil
+	Hash       string     `gorm:"type:char(64);not null;unique"` // sha256 of token
+	Label      string     `gorm:"type:varchar(255);not null;default:''"`
+	LastUsedAt *time.Time `gorm:"type:timestamp with time zone"`
+	Scope      string     `gorm:"type:varchar(16);not null;default:'full'"`
+
+	User   *User
+	UserID int64 `gorm:"not null;index"`
+}
+
+// Expired returns true if token is expired at given time
+func (t *AuthToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// AddAuthToken adds given plain token of scope for user, ttl 0 for never expires
+func AddAuthToken(userID int64, token, label, scope string, ttl time.Duration) (*AuthToken, error) {
+	authToken := &AuthToken{
+		Hash:   utils.Sha256(token),
+		Label:  utils.Truncate(label, 255),
+		Scope:  scope,
+		UserID: userID,
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		authToken.ExpiresAt = &expiresAt
+	}
+	if res := db.Create(&authToken); res.Error != nil {
+		return nil, res.Error
+	}
+
+	return authToken, nil
+}
+
+// DeleteAuthToken deletes token of user, returns deleted count
+func DeleteAuthToken(userID, id int64) (int64, error) {
+	res := db.Where("user_id = ?", userID).Delete(&AuthToken{ID: id})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+// GetAuthToken gets auth token with user data for plain token, nil for not found
+func GetAuthToken(token string) (*AuthToken, error) {
+	var authToken *AuthToken
+	if res := db.
+		Preload("User").
+		Where("hash = ?", utils.Sha256(token)).
+		First(&authToken); res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, res.Error
+	}
+
+	return authToken, nil
+}
+
+// IssueAuthToken issues a new random token for user, ttl 0 for never expires, returns the plain token
+func IssueAuthToken(userID int64, label string, ttl time.Duration) (string, *AuthToken, error) {
+	token, err := utils.SecureRandomHex(authTokenBytes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	authToken, err := AddAuthToken(userID, token, label, AuthTokenScopeFull, ttl)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, authToken, nil
+}
+
+// ListAuthTokens lists tokens of user
+func ListAuthTokens(userID int64) ([]*AuthToken, error) {
+	var authTokens []*AuthToken
+	if res := db.Where("user_id = ?", userID).Order("id").Find(&authTokens); res.Error != nil {
+		return nil, res.Error
+	}
+
+	return authTokens, nil
+}
+
+// TouchAuthToken updates the last used time of token
+func TouchAuthToken(id int64, at time.Time) error {
+	if res := db.Model(&AuthToken{ID: id}).Update("last_used_at", at); res.Error != nil {
+		return res.Error
+	}
+
+	return nil
+}
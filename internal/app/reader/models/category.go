@@ -6,18 +6,23 @@ import (
 	"gorm.io/gorm"
 )
 
+// SharedUserID owner ID of categories shared by all users, e.g. categories of built-in feeds
+const SharedUserID int64 = 0
+
 // Category category
 type Category struct {
 	ID int64
 
-	Name string `gorm:"type:varchar(255);not null;unique"`
+	Name   string `gorm:"type:varchar(255);not null;index:idx_categories_user_name,unique"`
+	UserID int64  `gorm:"default:0;not null;index:idx_categories_user_name,unique"`
 
-	Feeds []*Feed
+	Feeds         []*Feed
+	Subscriptions []*Subscription
 }
 
-// AddCategory adds category
-func AddCategory(name string) (int64, error) {
-	category := &Category{Name: name}
+// AddCategory adds category of user, SharedUserID for shared category
+func AddCategory(userID int64, name string) (int64, error) {
+	category := &Category{Name: name, UserID: userID}
 	if res := db.Create(&category); res.Error != nil {
 		return 0, res.Error
 	}
@@ -25,10 +30,68 @@ func AddCategory(name string) (int64, error) {
 	return category.ID, nil
 }
 
-// GetCategoryIDForName gets the category ID for given name, -1 for not found
-func GetCategoryIDForName(name string) (int64, error) {
+// DeleteCategory deletes category after moving its subscriptions, feeds and webhooks to target category
+func DeleteCategory(id, targetID int64) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if res := tx.Model(&Subscription{}).
+			Where("category_id = ?", id).
+			Update("category_id", targetID); res.Error != nil {
+			return res.Error
+		}
+		if res := tx.Model(&Feed{}).
+			Where("category_id = ?", id).
+			Update("category_id", targetID); res.Error != nil {
+			return res.Error
+		}
+		if res := tx.Model(&Webhook{}).
+			Where("category_id = ?", id).
+			Update("category_id", targetID); res.Error != nil {
+			return res.Error
+		}
+		if res := tx.Delete(&Category{ID: id}); res.Error != nil {
+			return res.Error
+		}
+
+		return nil
+	})
+}
+
+// GetCategory gets the category of user or shared category for ID, nil for not found
+func GetCategory(userID, id int64) (*Category, error) {
+	var category *Category
+	if res := db.
+		Where("user_id IN ?", []int64{userID, SharedUserID}).
+		First(&category, id); res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, res.Error
+	}
+
+	return category, nil
+}
+
+// GetCategoryForName gets the category of user for given name, falls back to the shared one, nil for not found
+func GetCategoryForName(userID int64, name string) (*Category, error) {
 	var category *Category
-	if res := db.Where(&Category{Name: name}).First(&category); res.Error != nil {
+	if res := db.
+		Where("name = ?", name).
+		Where("user_id IN ?", []int64{userID, SharedUserID}).
+		Order("user_id DESC").
+		First(&category); res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, res.Error
+	}
+
+	return category, nil
+}
+
+// GetCategoryIDForName gets the category ID owned by user for given name, -1 for not found
+func GetCategoryIDForName(userID int64, name string) (int64, error) {
+	var category *Category
+	if res := db.Where("user_id = ?", userID).Where("name = ?", name).First(&category); res.Error != nil {
 		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
 			return -1, nil
 		}
@@ -38,12 +101,49 @@ func GetCategoryIDForName(name string) (int64, error) {
 	return category.ID, nil
 }
 
-// ListAllCategoriesWithFeeds gets all categories with feeds data
-func ListAllCategoriesWithFeeds() ([]*Category, error) {
+// ListCategories lists categories of user and shared categories
+func ListCategories(userID int64) ([]*Category, error) {
 	var categories []*Category
-	if res := db.Preload("Feeds").Find(&categories); res.Error != nil {
+	if res := db.
+		Where("user_id IN ?", []int64{userID, SharedUserID}).
+		Order("name").
+		Find(&categories); res.Error != nil {
 		return nil, res.Error
 	}
 
 	return categories, nil
 }
+
+// ListAllCategoriesWithFeeds gets categories of user and shared categories with subscriptions of user and feeds data
+func ListAllCategoriesWithFeeds(userID int64) ([]*Category, error) {
+	var categories []*Category
+	if res := db.
+		Preload("Subscriptions", "user_id = ?", userID).
+		Preload("Subscriptions.Feed").
+		Where("user_id IN ?", []int64{userID, SharedUserID}).
+		Find(&categories); res.Error != nil {
+		return nil, res.Error
+	}
+
+	return categories, nil
+}
+
+// RenameCategory renames category of user, merges into the existing one if name is taken
+func RenameCategory(userID, id int64, name string) error {
+	targetID, err := GetCategoryIDForName(userID, name)
+	if err != nil {
+		return err
+	}
+	if targetID == id {
+		return nil
+	}
+	if targetID != -1 {
+		return DeleteCategory(id, targetID)
+	}
+
+	if res := db.Model(&Category{ID: id}).Where("user_id = ?", userID).Update("name", name); res.Error != nil {
+		return res.Error
+	}
+
+	return nil
+}
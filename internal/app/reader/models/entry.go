@@ -7,35 +7,75 @@ import (
 	"gorm.io/gorm"
 
 	"reader/internal/app/reader"
+	"reader/internal/app/reader/events"
 )
 
 // Entry entry
 type Entry struct {
 	ID int64
 
-	Author   string    `gorm:"type:varchar(255)"`
-	Content  string    `gorm:"type:text"`
-	Date     time.Time `gorm:"type:timestamp with time zone"`
-	Favorite bool      `gorm:"default:false;index"`
-	GUID     string    `gorm:"type:varchar(760);not null;index:feed_id_guid,unique"`
-	Link     string    `gorm:"type:varchar(1023);not null"`
-	Read     bool      `gorm:"default:false;index;index:idx_entries_feed_read"`
-	Title    string    `gorm:"type:varchar(255);not null"`
+	Author    string    `gorm:"type:varchar(255)"`
+	Content   string    `gorm:"type:text"`
+	CreatedAt time.Time `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP;not null;index"`
+	Date      time.Time `gorm:"type:timestamp with time zone"`
+	GUID      string    `gorm:"type:varchar(760);not null;index:feed_id_guid,unique"`
+	Link      string    `gorm:"type:varchar(1023);not null"`
+	Title     string    `gorm:"type:varchar(255);not null"`
+
+	// written by indexEntry and only queried in SQL
+	SearchVector string `gorm:"type:tsvector;index:,type:gin;->:false;<-:false"`
 
 	Feed   *Feed
-	FeedID int64  `gorm:"index:idx_entries_feed_read;index:feed_id_guid,unique"`
+	FeedID int64  `gorm:"index:feed_id_guid,unique"`
 	Tags   []*Tag `gorm:"many2many:entry_tags"`
 }
 
-// AddEntry adds entries and returns inserted count
+// UnreadCount unread entry count of a feed, category or tag
+type UnreadCount struct {
+	ID     int64
+	Name   string
+	Count  int64
+	Newest time.Time
+}
+
+// AddEntry adds entry and returns its ID, the entry is indexed for search
 func AddEntry(entry *Entry) (int64, error) {
 	if res := db.Create(&entry); res.Error != nil {
 		return 0, res.Error
 	}
+	if err := indexEntry(db, entry); err != nil {
+		return 0, err
+	}
 
 	return entry.ID, nil
 }
 
+// PublishEntry publishes new entry to subscribers of its feed which have not hidden it
+func PublishEntry(entry *Entry) error {
+	if !events.Listening() {
+		return nil
+	}
+
+	var userIDs []int64
+	if res := db.Model(&Subscription{}).
+		Where("feed_id = ?", entry.FeedID).
+		Where("NOT EXISTS (?)", db.Table("entry_states").
+			Select("1").
+			Where("entry_states.user_id = subscriptions.user_id").
+			Where("entry_states.entry_id = ?", entry.ID).
+			Where("entry_states.hidden = true")).
+		Pluck("user_id", &userIDs); res.Error != nil {
+		return res.Error
+	}
+	events.Publish(&events.Event{
+		Type:     events.TypeEntries,
+		EntryIDs: []int64{entry.ID},
+		FeedID:   entry.FeedID,
+	}, userIDs...)
+
+	return nil
+}
+
 // AddEntryWithDateCount adds entries with date count offset
 func AddEntryWithDateCount(entry *Entry) (int64, error) {
 	var entryID int64
@@ -58,18 +98,15 @@ func AddEntryWithDateCount(entry *Entry) (int64, error) {
 
 // AllScope generates all scope for query
 func AllScope(db *gorm.DB) *gorm.DB {
-	return db.
-		Joins("JOIN feeds ON feeds.id = entries.feed_id").
-		Where("feeds.priority >= ?", int64(reader.PriorityNormal))
+	return db.Where("subscriptions.priority >= ?", int64(reader.PriorityNormal))
 }
 
 // CategoryScope generates category scope for query
 func CategoryScope(id int64) func(*gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
 		return db.
-			Joins("JOIN feeds ON feeds.id = entries.feed_id").
-			Where("feeds.priority >= ?", int64(reader.PriorityNormal)).
-			Where("feeds.category_id = ?", id)
+			Where("subscriptions.priority >= ?", int64(reader.PriorityNormal)).
+			Where("subscriptions.category_id = ?", id)
 	}
 }
 
@@ -86,6 +123,71 @@ func ContinuationScope(id int64, asc bool) func(*gorm.DB) *gorm.DB {
 	}
 }
 
+// CountEntries counts entries with conditions
+func CountEntries(scopes ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	var count int64
+	if res := db.Model(&Entry{}).
+		Scopes(scopes...).
+		Count(&count); res.Error != nil {
+		return 0, res.Error
+	}
+
+	return count, nil
+}
+
+// CountUnreadByCategory counts unread entries of user grouped by category
+func CountUnreadByCategory(userID int64) ([]*UnreadCount, error) {
+	return countUnread(
+		userID,
+		"categories.id",
+		"categories.name",
+		func(db *gorm.DB) *gorm.DB {
+			return db.Joins("JOIN categories ON categories.id = subscriptions.category_id")
+		})
+}
+
+// CountUnreadByFeed counts unread entries of user grouped by feed
+func CountUnreadByFeed(userID int64) ([]*UnreadCount, error) {
+	return countUnread(
+		userID,
+		"subscriptions.feed_id",
+		"COALESCE(NULLIF(subscriptions.title, ''), feeds.name)",
+		func(db *gorm.DB) *gorm.DB {
+			return db.Joins("JOIN feeds ON feeds.id = subscriptions.feed_id")
+		})
+}
+
+// CountUnreadByTag counts unread entries of user grouped by tag
+func CountUnreadByTag(userID int64) ([]*UnreadCount, error) {
+	return countUnread(
+		userID,
+		"tags.id",
+		"tags.name",
+		func(db *gorm.DB) *gorm.DB {
+			return db.
+				Joins("JOIN entry_tags ON entry_tags.entry_id = entries.id").
+				Joins("JOIN tags ON tags.id = entry_tags.tag_id").
+				Where("tags.user_id = ?", userID)
+		})
+}
+
+// CountUnreadForScopes counts unread entries of user selected by scopes
+func CountUnreadForScopes(userID int64, scopes ...func(*gorm.DB) *gorm.DB) (*UnreadCount, error) {
+	var count UnreadCount
+	if res := db.Model(&Entry{}).
+		Select(
+			"COUNT(*) AS count",
+			"COALESCE(MAX(entries.date), now()) AS newest").
+		Scopes(UserScope(userID)).
+		Scopes(scopes...).
+		Where("entry_states.read IS NOT TRUE").
+		Scan(&count); res.Error != nil {
+		return nil, res.Error
+	}
+
+	return &count, nil
+}
+
 // CountScope generates count scope for query
 func CountScope(n int) func(*gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
@@ -93,16 +195,36 @@ func CountScope(n int) func(*gorm.DB) *gorm.DB {
 	}
 }
 
-// ExistingGUIDs returns GUIDs that exist
-func ExistingGUIDs(gUIDs []string) ([]string, error) {
+func countUnread(userID int64, id, name string, scopes ...func(*gorm.DB) *gorm.DB) ([]*UnreadCount, error) {
+	var counts []*UnreadCount
+	if res := db.Model(&Entry{}).
+		Select(
+			id+" AS id",
+			name+" AS name",
+			"COUNT(*) AS count",
+			"MAX(entries.date) AS newest").
+		Scopes(UserScope(userID), AllScope).
+		Scopes(scopes...).
+		Where("entry_states.read IS NOT TRUE").
+		Group(id).
+		Group(name).
+		Scan(&counts); res.Error != nil {
+		return nil, res.Error
+	}
+
+	return counts, nil
+}
+
+// ExistingGUIDs returns GUIDs that exist in feed, including those of purged entries
+func ExistingGUIDs(feedID int64, gUIDs []string) ([]string, error) {
 	type result struct {
 		GUID string
 	}
 
 	var results []result
-	if res := db.Model(&Entry{}).
-		Select("guid").
-		Where("guid IN ?", gUIDs).
+	if res := db.Raw("? UNION ?",
+		db.Model(&Entry{}).Select("guid").Where("feed_id = ? AND guid IN ?", feedID, gUIDs),
+		db.Model(&EntryTombstone{}).Select("guid").Where("feed_id = ? AND guid IN ?", feedID, gUIDs)).
 		Scan(&results); res.Error != nil {
 		return nil, res.Error
 	}
@@ -175,13 +297,13 @@ func ListEntryIDs(scopes ...func(*gorm.DB) *gorm.DB) ([]int64, int, error) {
 	return ids, int(count), nil
 }
 
-// ListEntriesByIDs list Entries by IDs
-func ListEntriesByIDs(ids []int64, asc bool) ([]*Entry, error) {
+// ListEntriesByIDs list Entries of feeds subscribed by user by IDs
+func ListEntriesByIDs(userID int64, ids []int64, asc bool) ([]*Entry, error) {
 	// TODO: split for chunk ?
 
 	var entries []*Entry
 	if res := db.
-		Preload("Tags").
+		Joins("JOIN subscriptions ON subscriptions.feed_id = entries.feed_id AND subscriptions.user_id = ?", userID).
 		Scopes(OrderScope(asc)).
 		Find(&entries, ids); res.Error != nil {
 		return nil, res.Error
@@ -190,24 +312,44 @@ func ListEntriesByIDs(ids []int64, asc bool) ([]*Entry, error) {
 	return entries, nil
 }
 
-// MarkFavorite marks entries for favorite state
-func MarkFavorite(ids []int64, favorite bool) (int64, error) {
-	res := db.Model(&Entry{}).Where("id IN ?", ids).Update("favorite", favorite)
+// MarkReadForScopes marks unread entries of user selected by scopes as read in a single statement
+func MarkReadForScopes(userID int64, scopes ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	now := time.Now()
+	ids := db.Model(&Entry{}).
+		Select("entries.id").
+		Scopes(UserScope(userID)).
+		Scopes(scopes...).
+		Where("entry_states.read IS NOT TRUE")
+
+	var changedIDs []int64
+	res := db.Raw(
+		"INSERT INTO entry_states (user_id, entry_id, read, read_at, updated_at) "+
+			"SELECT ?, id, true, ?, ? FROM entries WHERE id IN (?) "+
+			"ON CONFLICT (user_id, entry_id) DO UPDATE "+
+			"SET read = true, read_at = EXCLUDED.read_at, updated_at = EXCLUDED.updated_at "+
+			"RETURNING entry_id",
+		userID, now, now, ids).Scan(&changedIDs)
 	if res.Error != nil {
 		return 0, res.Error
 	}
 
-	return res.RowsAffected, nil
+	if len(changedIDs) > 0 {
+		events.Publish(&events.Event{Type: events.TypeRead, EntryIDs: changedIDs}, userID)
+	}
+
+	return int64(len(changedIDs)), nil
 }
 
-// MarkRead marks entries for read state
-func MarkRead(ids []int64, read bool) (int64, error) {
-	res := db.Model(&Entry{}).Where("id IN ?", ids).Update("read", read)
-	if res.Error != nil {
-		return 0, res.Error
+// ModifiedSinceScope generates scope for entries added or with states changed since given time
+func ModifiedSinceScope(since time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("entries.created_at >= ? OR entry_states.updated_at >= ?", since, since)
 	}
+}
 
-	return res.RowsAffected, nil
+// NoneScope generates scope selecting no entries
+func NoneScope(db *gorm.DB) *gorm.DB {
+	return db.Where("false")
 }
 
 // OrderScope generates order scope for query
@@ -226,9 +368,8 @@ func OrderScope(asc bool) func(*gorm.DB) *gorm.DB {
 // StarredScope generates starred scope for query
 func StarredScope(db *gorm.DB) *gorm.DB {
 	return db.
-		Joins("JOIN feeds ON feeds.id = entries.feed_id").
-		Where("feeds.priority >= ?", int64(reader.PriorityNormal)).
-		Where("entries.favorite = true")
+		Where("subscriptions.priority >= ?", int64(reader.PriorityNormal)).
+		Where("entry_states.starred = true")
 }
 
 // StartTimeScope generates start time scope for query
@@ -243,18 +384,18 @@ func StateScope(state reader.State) func(*gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
 		if state&reader.StateNotRead != 0 {
 			if state&reader.StateRead == 0 {
-				db = db.Where("entries.read = false")
+				db = db.Where("entry_states.read IS NOT TRUE")
 			}
 		} else if state&reader.StateRead != 0 {
-			db = db.Where("entries.read = true")
+			db = db.Where("entry_states.read = true")
 		}
 
 		if state&reader.StateFavorite != 0 {
 			if state&reader.StateNotFavorite == 0 {
-				db = db.Where("entries.favorite = true")
+				db = db.Where("entry_states.starred = true")
 			}
 		} else if state&reader.StateNotFavorite != 0 {
-			db = db.Where("entries.favorite = false")
+			db = db.Where("entry_states.starred IS NOT TRUE")
 		}
 
 		return db
@@ -272,9 +413,19 @@ func StopTimeScope(time time.Time) func(*gorm.DB) *gorm.DB {
 func TagScope(id int64) func(*gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
 		return db.
-			Joins("JOIN feeds ON feeds.id = entries.feed_id").
-			Where("feeds.priority >= ?", int64(reader.PriorityNormal)).
+			Where("subscriptions.priority >= ?", int64(reader.PriorityNormal)).
 			Joins("JOIN entry_tags ON entry_tags.entry_id = entries.id").
 			Where("entry_tags.tag_id = ?", id)
 	}
 }
+
+// UserScope generates user scope for query, it selects entries of feeds subscribed by user with their states
+// except the hidden ones, and must be applied before the other entry scopes
+func UserScope(userID int64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Joins("JOIN subscriptions ON subscriptions.feed_id = entries.feed_id AND subscriptions.user_id = ?", userID).
+			Joins("LEFT JOIN entry_states ON entry_states.entry_id = entries.id AND entry_states.user_id = ?", userID).
+			Where("entry_states.hidden IS NOT TRUE")
+	}
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"reader/internal/app/reader/events"
+)
+
+// EntryState read, starred and hidden state of entry for user, missing rows are unread, not starred and shown
+type EntryState struct {
+	UserID  int64 `gorm:"primaryKey;autoIncrement:false"`
+	EntryID int64 `gorm:"primaryKey;autoIncrement:false;index"`
+
+	Hidden    bool       `gorm:"default:false;not null"` // deleted by rule of user
+	HiddenAt  *time.Time `gorm:"type:timestamp with time zone"`
+	Read      bool       `gorm:"default:false;not null"`
+	ReadAt    *time.Time `gorm:"type:timestamp with time zone"`
+	Starred   bool       `gorm:"default:false;not null"`
+	StarredAt *time.Time `gorm:"type:timestamp with time zone"`
+	UpdatedAt *time.Time `gorm:"type:timestamp with time zone;index"` // last change of any state
+}
+
+// GetEntryStates gets entry states of user for entry IDs
+func GetEntryStates(userID int64, entryIDs []int64) (map[int64]*EntryState, error) {
+	var states []*EntryState
+	if res := db.
+		Where("user_id = ?", userID).
+		Where("entry_id IN ?", entryIDs).
+		Find(&states); res.Error != nil {
+		return nil, res.Error
+	}
+
+	entryStates := make(map[int64]*EntryState)
+	for _, state := range states {
+		entryStates[state.EntryID] = state
+	}
+
+	return entryStates, nil
+}
+
+// MarkHidden marks entries as hidden for user, which deletes them from all streams of user
+func MarkHidden(userID int64, ids []int64) (int64, error) {
+	return markEntryStates(userID, ids, "hidden", true, events.TypeDeleted)
+}
+
+// MarkRead marks entries for read state of user
+func MarkRead(userID int64, ids []int64, read bool) (int64, error) {
+	eventType := events.TypeUnread
+	if read {
+		eventType = events.TypeRead
+	}
+
+	return markEntryStates(userID, ids, "read", read, eventType)
+}
+
+// MarkStarred marks entries for starred state of user
+func MarkStarred(userID int64, ids []int64, starred bool) (int64, error) {
+	eventType := events.TypeUnstarred
+	if starred {
+		eventType = events.TypeStarred
+	}
+
+	return markEntryStates(userID, ids, "starred", starred, eventType)
+}
+
+// markEntryStates upserts state column of entries subscribed by user, the timestamp is kept if state is unchanged,
+// the changed entries are published as event
+func markEntryStates(userID int64, ids []int64, column string, value bool, eventType string) (int64, error) {
+	now := time.Now()
+	var at *time.Time
+	if value {
+		at = &now
+	}
+
+	var changedIDs []int64
+	res := db.Raw(fmt.Sprintf(
+		"INSERT INTO entry_states (user_id, entry_id, %[1]s, %[1]s_at, updated_at) "+
+			"SELECT ?, entries.id, ?, ?, ? FROM entries "+
+			"JOIN subscriptions ON subscriptions.feed_id = entries.feed_id AND subscriptions.user_id = ? "+
+			"WHERE entries.id IN ? "+
+			"ON CONFLICT (user_id, entry_id) DO UPDATE "+
+			"SET %[1]s = EXCLUDED.%[1]s, %[1]s_at = EXCLUDED.%[1]s_at, updated_at = EXCLUDED.updated_at "+
+			"WHERE entry_states.%[1]s IS DISTINCT FROM EXCLUDED.%[1]s "+
+			"RETURNING entry_id",
+		column), userID, value, at, now, userID, ids).Scan(&changedIDs)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	if len(changedIDs) > 0 {
+		events.Publish(&events.Event{Type: eventType, EntryIDs: changedIDs}, userID)
+	}
+
+	return int64(len(changedIDs)), nil
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -13,13 +14,25 @@ type Feed struct {
 	ID int64
 
 	Name     string `gorm:"type:varchar(255);not null;index"`
-	Priority int8   `gorm:"default:10;not null;index"`
+	Priority int8   `gorm:"default:10;not null;index"` // default priority of new subscriptions
 	URL      string `gorm:"type:varchar(255);not null;unique"`
 	Website  string `gorm:"type:varchar(255)"`
 
-	Category   *Category
-	CategoryID int64
-	Entries    []*Entry
+	CheckInterval int32      `gorm:"default:600;not null"` // seconds
+	ETag          string     `gorm:"type:varchar(255)"`
+	FailureCount  int32      `gorm:"default:0;not null"`
+	LastError     string     `gorm:"type:varchar(1023)"`
+	LastModified  string     `gorm:"type:varchar(63)"`
+	LastSuccessAt *time.Time `gorm:"type:timestamp with time zone"`
+	NextCheckAt   time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP;not null;index"`
+
+	RetentionCount *int32 // newest entries kept, nil for the global setting, 0 keeps all
+	RetentionDays  *int32 // days entries are kept, nil for the global setting, 0 keeps all
+
+	Category      *Category
+	CategoryID    int64 // default category of new subscriptions
+	Entries       []*Entry
+	Subscriptions []*Subscription
 }
 
 // AddFeed adds a feed
@@ -38,8 +51,64 @@ func AddFeed(name string, priority int8, url, website string, categoryID int64)
 	return feed.ID, nil
 }
 
-// GetFeedAndCategoryNames gets the feed names that have category names
-func GetFeedAndCategoryNames() (map[int64]*reader.FeedCategoryName, error) {
+// DeleteFeed deletes feed with its subscriptions, entries, their states and tombstones
+func DeleteFeed(id int64) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		entryIDs := tx.Model(&Entry{}).Select("id").Where("feed_id = ?", id)
+		if res := tx.Exec("DELETE FROM entry_tags WHERE entry_id IN (?)", entryIDs); res.Error != nil {
+			return res.Error
+		}
+		if res := tx.Exec("DELETE FROM entry_states WHERE entry_id IN (?)", entryIDs); res.Error != nil {
+			return res.Error
+		}
+		if res := tx.Where("feed_id = ?", id).Delete(&Entry{}); res.Error != nil {
+			return res.Error
+		}
+		if res := tx.Where("feed_id = ?", id).Delete(&EntryTombstone{}); res.Error != nil {
+			return res.Error
+		}
+		if res := tx.Where("feed_id = ?", id).Delete(&Subscription{}); res.Error != nil {
+			return res.Error
+		}
+		if res := tx.Delete(&Feed{ID: id}); res.Error != nil {
+			return res.Error
+		}
+
+		return nil
+	})
+}
+
+// GetFeed gets feed with ID, nil for not found
+func GetFeed(id int64) (*Feed, error) {
+	var feed *Feed
+	if res := db.First(&feed, id); res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, res.Error
+	}
+
+	return feed, nil
+}
+
+// GetFeedAndCategoryName gets the subscription name of user for feed with its category name, nil for not found
+func GetFeedAndCategoryName(userID, feedID int64) (*reader.FeedCategoryName, error) {
+	names, err := feedAndCategoryNames(userID, func(db *gorm.DB) *gorm.DB {
+		return db.Where("subscriptions.feed_id = ?", feedID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return names[feedID], nil
+}
+
+// GetFeedAndCategoryNames gets the subscription names of user with their category names
+func GetFeedAndCategoryNames(userID int64) (map[int64]*reader.FeedCategoryName, error) {
+	return feedAndCategoryNames(userID)
+}
+
+func feedAndCategoryNames(userID int64, scopes ...func(*gorm.DB) *gorm.DB) (map[int64]*reader.FeedCategoryName, error) {
 	type result struct {
 		FeedID       int64
 		FeedName     string
@@ -47,12 +116,15 @@ func GetFeedAndCategoryNames() (map[int64]*reader.FeedCategoryName, error) {
 	}
 
 	var results []*result
-	if res := db.Model(&Feed{}).
+	if res := db.Model(&Subscription{}).
 		Select(
-			"feeds.id AS feed_id",
-			"feeds.name AS feed_name",
+			"subscriptions.feed_id AS feed_id",
+			"COALESCE(NULLIF(subscriptions.title, ''), feeds.name) AS feed_name",
 			"categories.name AS category_name").
-		Joins("JOIN categories ON categories.id = feeds.category_id").
+		Joins("JOIN feeds ON feeds.id = subscriptions.feed_id").
+		Joins("JOIN categories ON categories.id = subscriptions.category_id").
+		Where("subscriptions.user_id = ?", userID).
+		Scopes(scopes...).
 		Scan(&results); res.Error != nil {
 		return nil, res.Error
 	}
@@ -68,6 +140,22 @@ func GetFeedAndCategoryNames() (map[int64]*reader.FeedCategoryName, error) {
 	return names, nil
 }
 
+// GetLastSuccessAt gets the last successful fetch time of feeds subscribed by user, nil for never fetched
+func GetLastSuccessAt(userID int64) (*time.Time, error) {
+	var result struct {
+		LastSuccessAt *time.Time
+	}
+	if res := db.Model(&Feed{}).
+		Select("MAX(feeds.last_success_at) AS last_success_at").
+		Joins("JOIN subscriptions ON subscriptions.feed_id = feeds.id").
+		Where("subscriptions.user_id = ?", userID).
+		Scan(&result); res.Error != nil {
+		return nil, res.Error
+	}
+
+	return result.LastSuccessAt, nil
+}
+
 // GetFeedIDForURL gets the feed ID for given URL, -1 for not found
 func GetFeedIDForURL(url string) (int64, error) {
 	var feed *Feed
@@ -80,3 +168,55 @@ func GetFeedIDForURL(url string) (int64, error) {
 
 	return feed.ID, nil
 }
+
+// ListDueFeeds lists feeds which should be checked before given time
+func ListDueFeeds(before time.Time) ([]*Feed, error) {
+	var feeds []*Feed
+	if res := db.
+		Where("next_check_at <= ?", before).
+		Order("next_check_at").
+		Find(&feeds); res.Error != nil {
+		return nil, res.Error
+	}
+
+	return feeds, nil
+}
+
+// ListFeeds lists all feeds
+func ListFeeds() ([]*Feed, error) {
+	var feeds []*Feed
+	if res := db.Order("id").Find(&feeds); res.Error != nil {
+		return nil, res.Error
+	}
+
+	return feeds, nil
+}
+
+// UpdateFeedRetention updates the retention settings of feed
+func UpdateFeedRetention(id int64, count, days *int32) error {
+	if res := db.Model(&Feed{ID: id}).
+		Select("retention_count", "retention_days").
+		Updates(&Feed{RetentionCount: count, RetentionDays: days}); res.Error != nil {
+		return res.Error
+	}
+
+	return nil
+}
+
+// UpdateFeedState updates the fetch schedule and conditional request state of feed
+func UpdateFeedState(feed *Feed) error {
+	if res := db.Model(feed).
+		Select(
+			"check_interval",
+			"e_tag",
+			"failure_count",
+			"last_error",
+			"last_modified",
+			"last_success_at",
+			"next_check_at").
+		Updates(feed); res.Error != nil {
+		return res.Error
+	}
+
+	return nil
+}
@@ -6,17 +6,91 @@ import (
 
 var (
 	db *gorm.DB
+
+	// feeds were shared by all users before subscriptions
+	migrateSubscriptions bool
 )
 
 // Initialize collects all models
 func Initialize(pg *gorm.DB) []interface{} {
 	db = pg
 
+	m := db.Migrator()
+	migrateSubscriptions = m.HasTable(&Feed{}) && !m.HasTable(&Subscription{})
+
 	return []interface{}{
+		&AuthEvent{},
+		&AuthToken{},
 		&Category{},
 		&Entry{},
+		&EntryState{},
+		&EntryTombstone{},
 		&Feed{},
+		&Output{},
+		&Rule{},
+		&SavedSearch{},
+		&Subscription{},
 		&Tag{},
 		&User{},
+		&Webhook{},
+		&WebhookDelivery{},
 	}
 }
+
+// Migrate migrates data of previous schemas, it runs after tables are synced
+func Migrate() error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		// category and tag names were unique for all users
+		for _, constraint := range []string{
+			"ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_name_key",
+			"ALTER TABLE tags DROP CONSTRAINT IF EXISTS tags_name_key",
+		} {
+			if res := tx.Exec(constraint); res.Error != nil {
+				return res.Error
+			}
+		}
+
+		// tags without owner were created by the first user
+		if res := tx.Exec(
+			"UPDATE tags SET user_id = (SELECT MIN(id) FROM users) " +
+				"WHERE user_id = 0 AND EXISTS (SELECT 1 FROM users)"); res.Error != nil {
+			return res.Error
+		}
+
+		// read and favorite states were stored in entries for all users
+		m := tx.Migrator()
+		if m.HasColumn(&Entry{}, "read") && m.HasColumn(&Entry{}, "favorite") {
+			if res := tx.Exec(
+				"INSERT INTO entry_states (user_id, entry_id, read, read_at, starred, starred_at) " +
+					"SELECT users.id, entries.id, " +
+					"entries.read, CASE WHEN entries.read THEN now() END, " +
+					"entries.favorite, CASE WHEN entries.favorite THEN now() END " +
+					"FROM users CROSS JOIN entries " +
+					"WHERE entries.read OR entries.favorite " +
+					"ON CONFLICT DO NOTHING"); res.Error != nil {
+				return res.Error
+			}
+			if err := m.DropColumn(&Entry{}, "read"); err != nil {
+				return err
+			}
+			if err := m.DropColumn(&Entry{}, "favorite"); err != nil {
+				return err
+			}
+		}
+
+		// every user subscribes to the existing feeds in categories visible to the user
+		if migrateSubscriptions {
+			if res := tx.Exec(
+				"INSERT INTO subscriptions (user_id, feed_id, category_id, priority) " +
+					"SELECT users.id, feeds.id, feeds.category_id, feeds.priority " +
+					"FROM users CROSS JOIN feeds " +
+					"JOIN categories ON categories.id = feeds.category_id " +
+					"WHERE categories.user_id IN (0, users.id) " +
+					"ON CONFLICT DO NOTHING"); res.Error != nil {
+				return res.Error
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"reader/internal/pkg/utils"
+)
+
+// Output public feed output of a stream of user, read with a secret token of which only the hash is stored
+type Output struct {
+	ID int64
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone"`
+	Hash      string    `gorm:"type:char(64);not null;unique"` // sha256 of token
+	StreamID  string    `gorm:"type:varchar(1023);not null"`   // Google Reader stream ID
+
+	User   *User
+	UserID int64 `gorm:"not null;index"`
+}
+
+// AddOutput adds output of stream for user with given plain token
+func AddOutput(userID int64, streamID, token string) (*Output, error) {
+	output := &Output{
+		Hash:     utils.Sha256(token),
+		StreamID: streamID,
+		UserID:   userID,
+	}
+	if res := db.Create(&output); res.Error != nil {
+		return nil, res.Error
+	}
+
+	return output, nil
+}
+
+// DeleteOutput deletes output of user, returns deleted count
+func DeleteOutput(userID, id int64) (int64, error) {
+	res := db.Where("user_id = ?", userID).Delete(&Output{ID: id})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+// GetOutputForToken gets output with user data for plain token, nil for not found
+func GetOutputForToken(token string) (*Output, error) {
+	var output *Output
+	if res := db.
+		Preload("User").
+		Where("hash = ?", utils.Sha256(token)).
+		First(&output); res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, res.Error
+	}
+
+	return output, nil
+}
+
+// ListOutputs lists outputs of user
+func ListOutputs(userID int64) ([]*Output, error) {
+	var outputs []*Output
+	if res := db.Where("user_id = ?", userID).Order("id").Find(&outputs); res.Error != nil {
+		return nil, res.Error
+	}
+
+	return outputs, nil
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EntryTombstone GUID of purged entry, kept so that the entry is not imported again
+type EntryTombstone struct {
+	FeedID int64  `gorm:"primaryKey;autoIncrement:false"`
+	GUID   string `gorm:"type:varchar(760);primaryKey;index"`
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone"`
+}
+
+// protectedScope excludes entries starred or tagged by any user, they are never purged
+func protectedScope(table string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where("NOT EXISTS (SELECT 1 FROM entry_states WHERE entry_states.entry_id = " + table + ".id AND entry_states.starred)").
+			Where("NOT EXISTS (SELECT 1 FROM entry_tags WHERE entry_tags.entry_id = " + table + ".id)")
+	}
+}
+
+// ListPurgeableEntryIDs lists entries of feed outside the keep newest entries and published before given time,
+// oldest first, keep 0 or zero time disables the limit, entries starred or tagged by any user are excluded
+func ListPurgeableEntryIDs(feedID int64, keep int, before time.Time) ([]int64, error) {
+	if keep <= 0 && before.IsZero() {
+		return nil, nil
+	}
+
+	ranked := db.Model(&Entry{}).
+		Select("id", "date", "ROW_NUMBER() OVER (ORDER BY date DESC, id DESC) AS rank").
+		Where("feed_id = ?", feedID)
+
+	query := db.Table("(?) AS ranked", ranked).
+		Scopes(protectedScope("ranked")).
+		Order("ranked.id")
+	if keep > 0 {
+		query = query.Where("ranked.rank > ?", keep)
+	}
+	if !before.IsZero() {
+		query = query.Where("ranked.date < ?", before)
+	}
+
+	var ids []int64
+	if res := query.Pluck("ranked.id", &ids); res.Error != nil {
+		return nil, res.Error
+	}
+
+	return ids, nil
+}
+
+// PurgeEntries deletes entries with their states and keeps their GUIDs as tombstones, entries which became starred
+// or tagged meanwhile are kept, returns the purged count
+func PurgeEntries(ids []int64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	var purgedIDs []int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		purgeable := tx.Model(&Entry{}).Select("entries.id").Where("entries.id IN ?", ids).Scopes(protectedScope("entries"))
+		if res := tx.Raw(
+			"WITH purged AS (DELETE FROM entries WHERE id IN (?) RETURNING id, feed_id, guid), "+
+				"tombstones AS (INSERT INTO entry_tombstones (feed_id, guid, created_at) "+
+				"SELECT feed_id, guid, now() FROM purged ON CONFLICT DO NOTHING) "+
+				"SELECT id FROM purged",
+			purgeable).Scan(&purgedIDs); res.Error != nil {
+			return res.Error
+		}
+		if len(purgedIDs) == 0 {
+			return nil
+		}
+
+		if res := tx.Exec("DELETE FROM entry_states WHERE entry_id IN ?", purgedIDs); res.Error != nil {
+			return res.Error
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(purgedIDs)), nil
+}
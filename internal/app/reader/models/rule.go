@@ -0,0 +1,128 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Rule actions
+const (
+	RuleActionDelete = "delete" // hides entry from all streams of user
+	RuleActionNotify = "notify" // publishes notify event
+	RuleActionRead   = "read"
+	RuleActionStar   = "star"
+	RuleActionTag    = "tag"
+)
+
+// Rule condition fields
+const (
+	RuleFieldAuthor   = "author"
+	RuleFieldCategory = "category"
+	RuleFieldContent  = "content" // text of content
+	RuleFieldFeed     = "feed"    // subscription title, or feed ID for equals
+	RuleFieldLink     = "link"
+	RuleFieldTitle    = "title"
+)
+
+// Rule condition operators, contains and equals ignore case
+const (
+	RuleOperatorContains = "contains"
+	RuleOperatorEquals   = "equals"
+	RuleOperatorMatches  = "matches" // regular expression
+)
+
+// Rule automatic actions of user on new entries which match all conditions
+type Rule struct {
+	ID int64
+
+	Actions    []*RuleAction    `gorm:"type:jsonb;serializer:json;not null"`
+	Conditions []*RuleCondition `gorm:"type:jsonb;serializer:json;not null"`
+	CreatedAt  time.Time        `gorm:"type:timestamp with time zone"`
+	Enabled    bool             `gorm:"not null"`
+	Name       string           `gorm:"type:varchar(63);not null"`
+
+	User   *User
+	UserID int64 `gorm:"not null;index"`
+}
+
+// RuleAction action of rule, TagID is set for tag actions
+type RuleAction struct {
+	Type  string `json:"type"`
+	TagID int64  `json:"tagId,omitempty"`
+}
+
+// RuleCondition condition of rule on a field of entry
+type RuleCondition struct {
+	Field    string `json:"field"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// AddRule adds rule
+func AddRule(rule *Rule) (int64, error) {
+	if res := db.Create(&rule); res.Error != nil {
+		return 0, res.Error
+	}
+
+	return rule.ID, nil
+}
+
+// DeleteRule deletes rule of user, returns deleted count
+func DeleteRule(userID, id int64) (int64, error) {
+	res := db.Where("user_id = ?", userID).Delete(&Rule{ID: id})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+// GetRule gets rule of user, nil for not found
+func GetRule(userID, id int64) (*Rule, error) {
+	var rule *Rule
+	if res := db.Where("user_id = ?", userID).First(&rule, id); res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, res.Error
+	}
+
+	return rule, nil
+}
+
+// ListEnabledRulesForFeed lists enabled rules of the subscribers of feed in creation order
+func ListEnabledRulesForFeed(feedID int64) ([]*Rule, error) {
+	var rules []*Rule
+	if res := db.
+		Joins("JOIN subscriptions ON subscriptions.user_id = rules.user_id AND subscriptions.feed_id = ?", feedID).
+		Where("rules.enabled = true").
+		Order("rules.id").
+		Find(&rules); res.Error != nil {
+		return nil, res.Error
+	}
+
+	return rules, nil
+}
+
+// ListRules lists rules of user in creation order
+func ListRules(userID int64) ([]*Rule, error) {
+	var rules []*Rule
+	if res := db.Where("user_id = ?", userID).Order("id").Find(&rules); res.Error != nil {
+		return nil, res.Error
+	}
+
+	return rules, nil
+}
+
+// UpdateRule updates name, state, conditions and actions of rule
+func UpdateRule(rule *Rule) error {
+	if res := db.Model(rule).
+		Select("actions", "conditions", "enabled", "name").
+		Updates(rule); res.Error != nil {
+		return res.Error
+	}
+
+	return nil
+}
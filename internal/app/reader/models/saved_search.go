@@ -0,0 +1,92 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SavedSearch stored filter query of user, read as a virtual label
+type SavedSearch struct {
+	ID int64
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone"`
+	Name      string    `gorm:"type:varchar(63);not null;uniqueIndex:idx_saved_searches_user_name"`
+	Query     string    `gorm:"type:varchar(1023);not null"`
+
+	User   *User
+	UserID int64 `gorm:"not null;uniqueIndex:idx_saved_searches_user_name"`
+}
+
+// AddSavedSearch adds saved search of user
+func AddSavedSearch(userID int64, name, query string) (int64, error) {
+	search := &SavedSearch{
+		Name:   name,
+		Query:  query,
+		UserID: userID,
+	}
+	if res := db.Create(&search); res.Error != nil {
+		return 0, res.Error
+	}
+
+	return search.ID, nil
+}
+
+// DeleteSavedSearch deletes saved search of user, returns deleted count
+func DeleteSavedSearch(userID, id int64) (int64, error) {
+	res := db.Where("user_id = ?", userID).Delete(&SavedSearch{ID: id})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+// GetSavedSearch gets saved search of user, nil for not found
+func GetSavedSearch(userID, id int64) (*SavedSearch, error) {
+	var search *SavedSearch
+	if res := db.Where("user_id = ?", userID).First(&search, id); res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, res.Error
+	}
+
+	return search, nil
+}
+
+// GetSavedSearchForName gets saved search of user for given name, nil for not found
+func GetSavedSearchForName(userID int64, name string) (*SavedSearch, error) {
+	var search *SavedSearch
+	if res := db.Where("user_id = ?", userID).Where("name = ?", name).First(&search); res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, res.Error
+	}
+
+	return search, nil
+}
+
+// ListSavedSearches lists saved searches of user
+func ListSavedSearches(userID int64) ([]*SavedSearch, error) {
+	var searches []*SavedSearch
+	if res := db.Where("user_id = ?", userID).Order("name").Find(&searches); res.Error != nil {
+		return nil, res.Error
+	}
+
+	return searches, nil
+}
+
+// UpdateSavedSearch updates name and query of saved search of user
+func UpdateSavedSearch(userID, id int64, name, query string) error {
+	if res := db.Model(&SavedSearch{}).
+		Where("id = ?", id).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{"name": name, "query": query}); res.Error != nil {
+		return res.Error
+	}
+
+	return nil
+}
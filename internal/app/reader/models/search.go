@@ -0,0 +1,72 @@
+package models
+
+import (
+	"html"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"reader/internal/pkg/search"
+)
+
+const (
+	indexBatchSize = 500
+)
+
+// indexEntry updates the search vector of entry, title weighs most, then author, then the text of content
+func indexEntry(tx *gorm.DB, entry *Entry) error {
+	config := search.Config()
+	if res := tx.Exec(
+		"UPDATE entries SET search_vector = "+
+			"setweight(to_tsvector(?::regconfig, ?), 'A') || "+
+			"setweight(to_tsvector(?::regconfig, ?), 'B') || "+
+			"setweight(to_tsvector(?::regconfig, ?), 'C') "+
+			"WHERE id = ?",
+		config, search.Segment(html.UnescapeString(entry.Title)),
+		config, search.Segment(html.UnescapeString(entry.Author)),
+		config, search.Segment(search.StripHTML(entry.Content)),
+		entry.ID); res.Error != nil {
+		return res.Error
+	}
+
+	return nil
+}
+
+// IndexEntries indexes entries added before search, returns the indexed count
+func IndexEntries() (int, error) {
+	count := 0
+	lastID := int64(0)
+	for {
+		var entries []*Entry
+		if res := db.
+			Select("id", "author", "content", "title").
+			Where("search_vector IS NULL").
+			Where("id > ?", lastID).
+			Order("id").
+			Limit(indexBatchSize).
+			Find(&entries); res.Error != nil {
+			return count, res.Error
+		}
+
+		for _, entry := range entries {
+			if err := indexEntry(db, entry); err != nil {
+				return count, err
+			}
+			lastID = entry.ID
+			count++
+		}
+
+		if len(entries) < indexBatchSize {
+			return count, nil
+		}
+	}
+}
+
+// SearchScope generates full-text search scope for query, which supports quoted phrases, or and -exclusion
+func SearchScope(query string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(
+			"entries.search_vector @@ websearch_to_tsquery(?::regconfig, ?)",
+			search.Config(), search.Segment(strings.TrimSpace(query)))
+	}
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// Subscription subscription of user to shared feed
+type Subscription struct {
+	ID int64
+
+	Priority int8   `gorm:"default:10;not null"`
+	Title    string `gorm:"type:varchar(255);not null;default:''"` // overrides feed name if not empty
+
+	Category   *Category
+	CategoryID int64 `gorm:"not null;index"`
+	Feed       *Feed
+	FeedID     int64 `gorm:"not null;index;index:idx_subscriptions_user_feed,unique"`
+	User       *User
+	UserID     int64 `gorm:"not null;index:idx_subscriptions_user_feed,unique"`
+}
+
+// Name returns the display name of subscription, feed should be loaded
+func (s *Subscription) Name() string {
+	if s.Title != "" || s.Feed == nil {
+		return s.Title
+	}
+
+	return s.Feed.Name
+}
+
+// AddSubscription subscribes user to feed
+func AddSubscription(userID, feedID, categoryID int64, priority int8, title string) (int64, error) {
+	subscription := &Subscription{
+		Priority:   priority,
+		Title:      title,
+		CategoryID: categoryID,
+		FeedID:     feedID,
+		UserID:     userID,
+	}
+	if res := db.Create(&subscription); res.Error != nil {
+		return 0, res.Error
+	}
+
+	return subscription.ID, nil
+}
+
+// AddSubscriptionForAllUsers subscribes all users to feed, existing subscriptions are kept
+func AddSubscriptionForAllUsers(feedID, categoryID int64, priority int8) error {
+	if res := db.Exec(
+		"INSERT INTO subscriptions (user_id, feed_id, category_id, priority) "+
+			"SELECT id, ?, ?, ? FROM users "+
+			"ON CONFLICT (user_id, feed_id) DO NOTHING",
+		feedID, categoryID, priority); res.Error != nil {
+		return res.Error
+	}
+
+	return nil
+}
+
+// CountFeedSubscriptions counts users subscribing to feed
+func CountFeedSubscriptions(feedID int64) (int64, error) {
+	var count int64
+	if res := db.Model(&Subscription{}).
+		Where("feed_id = ?", feedID).
+		Count(&count); res.Error != nil {
+		return 0, res.Error
+	}
+
+	return count, nil
+}
+
+// DeleteSubscription unsubscribes user from feed with the user's states of its entries
+func DeleteSubscription(userID, feedID int64) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		entryIDs := tx.Model(&Entry{}).Select("id").Where("feed_id = ?", feedID)
+		tagIDs := tx.Model(&Tag{}).Select("id").Where("user_id = ?", userID)
+		if res := tx.Exec(
+			"DELETE FROM entry_tags WHERE entry_id IN (?) AND tag_id IN (?)",
+			entryIDs, tagIDs); res.Error != nil {
+			return res.Error
+		}
+		if res := tx.Exec(
+			"DELETE FROM entry_states WHERE entry_id IN (?) AND user_id = ?",
+			entryIDs, userID); res.Error != nil {
+			return res.Error
+		}
+		if res := tx.
+			Where("user_id = ?", userID).
+			Where("feed_id = ?", feedID).
+			Delete(&Subscription{}); res.Error != nil {
+			return res.Error
+		}
+
+		return nil
+	})
+}
+
+// GetSubscription gets subscription of user to feed with feed data, nil for not found
+func GetSubscription(userID, feedID int64) (*Subscription, error) {
+	var subscription *Subscription
+	if res := db.
+		Preload("Feed").
+		Where("user_id = ?", userID).
+		Where("feed_id = ?", feedID).
+		First(&subscription); res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, res.Error
+	}
+
+	return subscription, nil
+}
+
+// UpdateSubscriptionCategory moves subscription of user to category
+func UpdateSubscriptionCategory(userID, feedID, categoryID int64) error {
+	if res := db.Model(&Subscription{}).
+		Where("user_id = ?", userID).
+		Where("feed_id = ?", feedID).
+		Update("category_id", categoryID); res.Error != nil {
+		return res.Error
+	}
+
+	return nil
+}
+
+// UpdateSubscriptionTitle overrides the feed name of subscription of user
+func UpdateSubscriptionTitle(userID, feedID int64, title string) error {
+	if res := db.Model(&Subscription{}).
+		Where("user_id = ?", userID).
+		Where("feed_id = ?", feedID).
+		Update("title", title); res.Error != nil {
+		return res.Error
+	}
+
+	return nil
+}
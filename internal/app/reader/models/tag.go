@@ -2,24 +2,28 @@ package models
 
 import (
 	"errors"
+	"html"
 
 	"gorm.io/gorm"
+
+	"reader/internal/app/reader/events"
 )
 
 // Tag tag
 type Tag struct {
 	ID int64
 
-	Name string `gorm:"type:varchar(63);unique;not null"`
+	Name   string `gorm:"type:varchar(63);not null;index:idx_tags_user_name,unique"`
+	UserID int64  `gorm:"default:0;not null;index:idx_tags_user_name,unique"`
 
 	Entries []*Entry `gorm:"many2many:entry_tags"`
 }
 
-// AddTag adds tag for name
-func AddTag(name string) (int64, error) {
+// AddTag adds tag of user for name
+func AddTag(userID int64, name string) (int64, error) {
 	// TODO: check name length
 
-	tag := &Tag{Name: name}
+	tag := &Tag{Name: name, UserID: userID}
 	if res := db.Create(&tag); res.Error != nil {
 		return 0, res.Error
 	}
@@ -39,13 +43,41 @@ func AddTagForEntries(tagID int64, entryIDs []int64) error {
 		return err
 	}
 
-	return nil
+	return publishTagEvent(events.TypeTagged, tagID, entryIDs)
+}
+
+// DeleteTag deletes tag of user with its entry associations
+func DeleteTag(userID, id int64) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		tagIDs := tx.Model(&Tag{}).Select("id").Where("id = ?", id).Where("user_id = ?", userID)
+		if res := tx.Exec("DELETE FROM entry_tags WHERE tag_id IN (?)", tagIDs); res.Error != nil {
+			return res.Error
+		}
+		if res := tx.Where("user_id = ?", userID).Delete(&Tag{ID: id}); res.Error != nil {
+			return res.Error
+		}
+
+		return nil
+	})
+}
+
+// GetTag gets the tag of user for ID, nil for not found
+func GetTag(userID, id int64) (*Tag, error) {
+	var tag *Tag
+	if res := db.Where("user_id = ?", userID).First(&tag, id); res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, res.Error
+	}
+
+	return tag, nil
 }
 
-// GetTagIDForName gets the tag ID for given name, -1 for not found
-func GetTagIDForName(name string) (int64, error) {
+// GetTagIDForName gets the tag ID of user for given name, -1 for not found
+func GetTagIDForName(userID int64, name string) (int64, error) {
 	var tag *Tag
-	if res := db.Where(&Tag{Name: name}).First(&tag); res.Error != nil {
+	if res := db.Where("user_id = ?", userID).Where("name = ?", name).First(&tag); res.Error != nil {
 		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
 			return -1, nil
 		}
@@ -55,8 +87,8 @@ func GetTagIDForName(name string) (int64, error) {
 	return tag.ID, nil
 }
 
-// GetTagNamesForEntryIDs gets tag names for entry IDs
-func GetTagNamesForEntryIDs(entryIDs []int64) (map[int64][]string, error) {
+// GetTagNamesForEntryIDs gets tag names of user for entry IDs
+func GetTagNamesForEntryIDs(userID int64, entryIDs []int64) (map[int64][]string, error) {
 	type result struct {
 		TagName string
 		EntryID int64
@@ -66,6 +98,7 @@ func GetTagNamesForEntryIDs(entryIDs []int64) (map[int64][]string, error) {
 	if res := db.Model(&Tag{}).
 		Select("tags.name AS tag_name", "entry_tags.entry_id AS entry_id").
 		Joins("JOIN entry_tags ON entry_tags.tag_id = tags.id AND entry_tags.entry_id IN ?", entryIDs).
+		Where("tags.user_id = ?", userID).
 		Scan(&results); res.Error != nil {
 		return nil, res.Error
 	}
@@ -78,6 +111,16 @@ func GetTagNamesForEntryIDs(entryIDs []int64) (map[int64][]string, error) {
 	return entryTagNames, nil
 }
 
+// ListTags lists all tags of user
+func ListTags(userID int64) ([]*Tag, error) {
+	var tags []*Tag
+	if res := db.Where("user_id = ?", userID).Order("name").Find(&tags); res.Error != nil {
+		return nil, res.Error
+	}
+
+	return tags, nil
+}
+
 // RemoveTagForEntries remove tag for entries
 func RemoveTagForEntries(tagID int64, entryIDs []int64) error {
 	var entries []*Entry
@@ -90,5 +133,62 @@ func RemoveTagForEntries(tagID int64, entryIDs []int64) error {
 		return err
 	}
 
+	return publishTagEvent(events.TypeUntagged, tagID, entryIDs)
+}
+
+// publishTagEvent publishes tag change of entries to the tag owner
+func publishTagEvent(eventType string, tagID int64, entryIDs []int64) error {
+	if len(entryIDs) == 0 || !events.Listening() {
+		return nil
+	}
+
+	var tag *Tag
+	if res := db.First(&tag, tagID); res.Error != nil {
+		return res.Error
+	}
+
+	events.Publish(&events.Event{
+		Type:     eventType,
+		EntryIDs: entryIDs,
+		Tag:      html.UnescapeString(tag.Name),
+	}, tag.UserID)
+
+	return nil
+}
+
+// RenameTag renames tag of user, merges into the existing one if name is taken
+func RenameTag(userID, id int64, name string) error {
+	targetID, err := GetTagIDForName(userID, name)
+	if err != nil {
+		return err
+	}
+	if targetID == id {
+		return nil
+	}
+
+	if targetID != -1 {
+		return db.Transaction(func(tx *gorm.DB) error {
+			if res := tx.Exec(
+				"INSERT INTO entry_tags (tag_id, entry_id) "+
+					"SELECT ?, entry_id FROM entry_tags WHERE tag_id = ? "+
+					"ON CONFLICT DO NOTHING",
+				targetID, id); res.Error != nil {
+				return res.Error
+			}
+			if res := tx.Exec("DELETE FROM entry_tags WHERE tag_id = ?", id); res.Error != nil {
+				return res.Error
+			}
+			if res := tx.Delete(&Tag{ID: id}); res.Error != nil {
+				return res.Error
+			}
+
+			return nil
+		})
+	}
+
+	if res := db.Model(&Tag{ID: id}).Where("user_id = ?", userID).Update("name", name); res.Error != nil {
+		return res.Error
+	}
+
 	return nil
 }
@@ -0,0 +1,245 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Webhook delivery states
+const (
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed" // no more attempts
+	WebhookDeliveryPending   = "pending"
+)
+
+// Webhook outgoing webhook of user for new entries, nil filters match all
+type Webhook struct {
+	ID int64
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone"`
+	Enabled   bool      `gorm:"default:true;not null"`
+	Keyword   string    `gorm:"type:varchar(255);not null;default:''"` // matches title or content case-insensitively
+	Secret    string    `gorm:"type:varchar(255);not null"`            // HMAC key of payload signature
+	URL       string    `gorm:"type:varchar(1023);not null"`
+
+	CategoryID *int64 `gorm:"index"`
+	FeedID     *int64 `gorm:"index"`
+	TagID      *int64 `gorm:"index"`
+	User       *User
+	UserID     int64 `gorm:"not null;index"`
+}
+
+// WebhookDelivery queued delivery of webhook payload, kept as delivery log
+type WebhookDelivery struct {
+	ID int64
+
+	Attempts       int32      `gorm:"default:0;not null"`
+	CreatedAt      time.Time  `gorm:"type:timestamp with time zone;index"`
+	DeliveredAt    *time.Time `gorm:"type:timestamp with time zone"`
+	Event          string     `gorm:"type:varchar(63);not null"`
+	LastAttemptAt  *time.Time `gorm:"type:timestamp with time zone"`
+	LastError      string     `gorm:"type:varchar(1023);not null;default:''"`
+	NextAttemptAt  time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP;not null;index"`
+	Payload        string     `gorm:"type:text;not null"`
+	ResponseStatus int        `gorm:"default:0;not null"`
+	Status         string     `gorm:"type:varchar(15);not null;index"`
+
+	EntryID   int64 `gorm:"not null"`
+	Webhook   *Webhook
+	WebhookID int64 `gorm:"not null;index"`
+}
+
+// AddWebhook adds webhook
+func AddWebhook(webhook *Webhook) (int64, error) {
+	if res := db.Create(&webhook); res.Error != nil {
+		return 0, res.Error
+	}
+
+	return webhook.ID, nil
+}
+
+// AddWebhookDeliveries queues deliveries
+func AddWebhookDeliveries(deliveries []*WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if res := db.Create(&deliveries); res.Error != nil {
+		return res.Error
+	}
+
+	return nil
+}
+
+// ClaimWebhookDeliveries claims pending deliveries due at now with webhook data,
+// they are not due again until lease passes so that concurrent workers skip them
+func ClaimWebhookDeliveries(now time.Time, limit int, lease time.Duration) ([]*WebhookDelivery, error) {
+	var deliveries []*WebhookDelivery
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if res := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", WebhookDeliveryPending).
+			Where("next_attempt_at <= ?", now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&deliveries); res.Error != nil {
+			return res.Error
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		var ids []int64
+		for _, delivery := range deliveries {
+			ids = append(ids, delivery.ID)
+		}
+		if res := tx.Model(&WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)); res.Error != nil {
+			return res.Error
+		}
+
+		return nil
+	})
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+
+	webhookIDs := make(map[int64]*Webhook)
+	var ids []int64
+	for _, delivery := range deliveries {
+		if _, ok := webhookIDs[delivery.WebhookID]; !ok {
+			webhookIDs[delivery.WebhookID] = nil
+			ids = append(ids, delivery.WebhookID)
+		}
+	}
+
+	var webhooks []*Webhook
+	if res := db.Where("id IN ?", ids).Find(&webhooks); res.Error != nil {
+		return nil, res.Error
+	}
+	for _, webhook := range webhooks {
+		webhookIDs[webhook.ID] = webhook
+	}
+	for _, delivery := range deliveries {
+		delivery.Webhook = webhookIDs[delivery.WebhookID]
+	}
+
+	return deliveries, nil
+}
+
+// DeleteWebhook deletes webhook of user with its deliveries, returns deleted count
+func DeleteWebhook(userID, id int64) (int64, error) {
+	var count int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		webhookIDs := tx.Model(&Webhook{}).Select("id").Where("id = ?", id).Where("user_id = ?", userID)
+		if res := tx.Where("webhook_id IN (?)", webhookIDs).Delete(&WebhookDelivery{}); res.Error != nil {
+			return res.Error
+		}
+
+		res := tx.Where("user_id = ?", userID).Delete(&Webhook{ID: id})
+		if res.Error != nil {
+			return res.Error
+		}
+		count = res.RowsAffected
+
+		return nil
+	})
+
+	return count, err
+}
+
+// GetWebhook gets webhook of user, nil for not found
+func GetWebhook(userID, id int64) (*Webhook, error) {
+	var webhook *Webhook
+	if res := db.Where("user_id = ?", userID).First(&webhook, id); res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, res.Error
+	}
+
+	return webhook, nil
+}
+
+// ListMatchingWebhooks lists enabled webhooks whose feed, category and tag filters match entry for its subscribers
+// which have not hidden it, keywords are left to the caller
+func ListMatchingWebhooks(entry *Entry) ([]*Webhook, error) {
+	var webhooks []*Webhook
+	if res := db.
+		Joins("JOIN subscriptions ON subscriptions.user_id = webhooks.user_id AND subscriptions.feed_id = ?", entry.FeedID).
+		Where("webhooks.enabled = true").
+		Where("webhooks.feed_id IS NULL OR webhooks.feed_id = ?", entry.FeedID).
+		Where("webhooks.category_id IS NULL OR webhooks.category_id = subscriptions.category_id").
+		Where("webhooks.tag_id IS NULL OR EXISTS (?)", db.Table("entry_tags").
+			Select("1").
+			Where("entry_tags.tag_id = webhooks.tag_id").
+			Where("entry_tags.entry_id = ?", entry.ID)).
+		Where("NOT EXISTS (?)", db.Table("entry_states").
+			Select("1").
+			Where("entry_states.user_id = webhooks.user_id").
+			Where("entry_states.entry_id = ?", entry.ID).
+			Where("entry_states.hidden = true")).
+		Order("webhooks.id").
+		Find(&webhooks); res.Error != nil {
+		return nil, res.Error
+	}
+
+	return webhooks, nil
+}
+
+// ListWebhookDeliveries lists recent deliveries of webhook, newest first
+func ListWebhookDeliveries(webhookID int64, limit int) ([]*WebhookDelivery, error) {
+	var deliveries []*WebhookDelivery
+	if res := db.
+		Where("webhook_id = ?", webhookID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&deliveries); res.Error != nil {
+		return nil, res.Error
+	}
+
+	return deliveries, nil
+}
+
+// ListWebhooks lists webhooks of user
+func ListWebhooks(userID int64) ([]*Webhook, error) {
+	var webhooks []*Webhook
+	if res := db.Where("user_id = ?", userID).Order("id").Find(&webhooks); res.Error != nil {
+		return nil, res.Error
+	}
+
+	return webhooks, nil
+}
+
+// UpdateWebhook updates the settings of webhook
+func UpdateWebhook(webhook *Webhook) error {
+	if res := db.Model(webhook).
+		Select("category_id", "enabled", "feed_id", "keyword", "secret", "tag_id", "url").
+		Updates(webhook); res.Error != nil {
+		return res.Error
+	}
+
+	return nil
+}
+
+// UpdateWebhookDelivery updates the attempt state of delivery
+func UpdateWebhookDelivery(delivery *WebhookDelivery) error {
+	if res := db.Model(delivery).
+		Select(
+			"attempts",
+			"delivered_at",
+			"last_attempt_at",
+			"last_error",
+			"next_attempt_at",
+			"response_status",
+			"status").
+		Updates(delivery); res.Error != nil {
+		return res.Error
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package opml
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// CategorySeparator joins names of nested outlines into a category name
+	CategorySeparator = "/"
+
+	version = "2.0"
+)
+
+// Document OPML document
+type Document struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+// Head OPML head
+type Head struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+// Body OPML body
+type Body struct {
+	Outlines []*Outline `xml:"outline"`
+}
+
+// Outline OPML outline, a folder if it has children, a subscription if it has xmlUrl
+type Outline struct {
+	Text     string     `xml:"text,attr"`
+	Title    string     `xml:"title,attr,omitempty"`
+	Type     string     `xml:"type,attr,omitempty"`
+	XMLURL   string     `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string     `xml:"htmlUrl,attr,omitempty"`
+	Outlines []*Outline `xml:"outline,omitempty"`
+}
+
+// Subscription flattened subscription of a document
+type Subscription struct {
+	Category string // nested folder names joined by CategorySeparator, empty at top level
+	Title    string
+	URL      string
+	Website  string
+}
+
+// CategoryFeeds feeds of a category for export
+type CategoryFeeds struct {
+	Name  string
+	Feeds []*Subscription
+}
+
+// Decode decodes OPML document
+func Decode(r io.Reader) (*Document, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false
+
+	var doc Document
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return &doc, nil
+}
+
+// Encode encodes categories as an OPML document, nested category names become nested outlines
+func Encode(w io.Writer, title string, categories []*CategoryFeeds) error {
+	doc := &Document{
+		Version: version,
+		Head: Head{
+			Title:       title,
+			DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+
+	folders := make(map[string]*Outline)
+	var folder func(name string) *Outline
+	folder = func(name string) *Outline {
+		if o, ok := folders[name]; ok {
+			return o
+		}
+
+		o := &Outline{}
+		idx := strings.LastIndex(name, CategorySeparator)
+		if idx == -1 {
+			o.Text = name
+			doc.Body.Outlines = append(doc.Body.Outlines, o)
+		} else {
+			o.Text = name[idx+len(CategorySeparator):]
+			parent := folder(name[:idx])
+			parent.Outlines = append(parent.Outlines, o)
+		}
+		o.Title = o.Text
+		folders[name] = o
+
+		return o
+	}
+
+	for _, category := range categories {
+		parent := folder(category.Name)
+		for _, feed := range category.Feeds {
+			parent.Outlines = append(parent.Outlines, &Outline{
+				Text:    feed.Title,
+				Title:   feed.Title,
+				Type:    "rss",
+				XMLURL:  feed.URL,
+				HTMLURL: feed.Website,
+			})
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Subscriptions flattens subscriptions of the document
+func (d *Document) Subscriptions() []*Subscription {
+	var subscriptions []*Subscription
+
+	var walk func(outlines []*Outline, category string)
+	walk = func(outlines []*Outline, category string) {
+		for _, o := range outlines {
+			name := strings.TrimSpace(o.Title)
+			if name == "" {
+				name = strings.TrimSpace(o.Text)
+			}
+
+			if url := strings.TrimSpace(o.XMLURL); url != "" {
+				subscriptions = append(subscriptions, &Subscription{
+					Category: category,
+					Title:    name,
+					URL:      url,
+					Website:  strings.TrimSpace(o.HTMLURL),
+				})
+				continue
+			}
+
+			child := name
+			if child == "" {
+				child = category
+			} else if category != "" {
+				child = category + CategorySeparator + name
+			}
+			walk(o.Outlines, child)
+		}
+	}
+	walk(d.Body.Outlines, "")
+
+	return subscriptions
+}
@@ -0,0 +1,104 @@
+package opml
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"reader/internal/app/reader/models"
+)
+
+func TestSubscriptions(t *testing.T) {
+	body := `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+	<head><title>Subscriptions</title></head>
+	<body>
+		<outline text="Top" type="rss" xmlUrl="https://example.com/top.xml" htmlUrl="https://example.com/" />
+		<outline text="Games" title="Games">
+			<outline text="Arknights" xmlUrl="https://example.com/ak.xml" />
+			<outline text="miHoYo">
+				<outline title="Genshin" text="ys" xmlUrl="https://example.com/ys.xml" />
+			</outline>
+		</outline>
+	</body>
+</opml>`
+
+	doc, err := Decode(strings.NewReader(body))
+	assert.Nil(t, err)
+
+	subscriptions := doc.Subscriptions()
+	assert.Len(t, subscriptions, 3)
+
+	assert.Equal(t, "", subscriptions[0].Category)
+	assert.Equal(t, "Top", subscriptions[0].Title)
+	assert.Equal(t, "https://example.com/", subscriptions[0].Website)
+
+	assert.Equal(t, "Games", subscriptions[1].Category)
+	assert.Equal(t, "https://example.com/ak.xml", subscriptions[1].URL)
+
+	assert.Equal(t, "Games/miHoYo", subscriptions[2].Category)
+	assert.Equal(t, "Genshin", subscriptions[2].Title)
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	err := Encode(&buf, "Export", []*CategoryFeeds{
+		{
+			Name: "Games",
+			Feeds: []*Subscription{
+				{Title: "Arknights", URL: "https://example.com/ak.xml"},
+			},
+		},
+		{
+			Name: "Games/miHoYo",
+			Feeds: []*Subscription{
+				{Title: "Genshin & Honkai", URL: "https://example.com/ys.xml", Website: "https://example.com/"},
+			},
+		},
+	})
+	assert.Nil(t, err)
+
+	doc, err := Decode(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "2.0", doc.Version)
+	assert.Equal(t, "Export", doc.Head.Title)
+	assert.Len(t, doc.Body.Outlines, 1)
+
+	subscriptions := doc.Subscriptions()
+	assert.Len(t, subscriptions, 2)
+	assert.Equal(t, "Games", subscriptions[0].Category)
+	assert.Equal(t, "Games/miHoYo", subscriptions[1].Category)
+	assert.Equal(t, "Genshin & Honkai", subscriptions[1].Title)
+	assert.Equal(t, "https://example.com/", subscriptions[1].Website)
+}
+
+func TestExportImportNames(t *testing.T) {
+	category := &models.Category{
+		Name: "A &amp; B",
+		Subscriptions: []*models.Subscription{
+			{Title: "&lt;Genshin&gt; &amp; Honkai", Feed: &models.Feed{URL: "https://example.com/ys.xml?a=1&b=2"}},
+			{Feed: &models.Feed{Name: "R&amp;D", URL: "https://example.com/rd.xml"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := Encode(&buf, "Export", []*CategoryFeeds{exportCategory(category)})
+	assert.Nil(t, err)
+	assert.NotContains(t, buf.String(), "&amp;amp;")
+
+	doc, err := Decode(&buf)
+	assert.Nil(t, err)
+
+	subscriptions := doc.Subscriptions()
+	assert.Len(t, subscriptions, 2)
+	assert.Equal(t, "A & B", subscriptions[0].Category)
+	assert.Equal(t, "<Genshin> & Honkai", subscriptions[0].Title)
+	assert.Equal(t, "https://example.com/ys.xml?a=1&b=2", subscriptions[0].URL)
+	assert.Equal(t, "R&D", subscriptions[1].Title)
+
+	assert.Equal(t, category.Name, importName(subscriptions[0].Category))
+	assert.Equal(t, category.Subscriptions[0].Title, importName(subscriptions[0].Title))
+	assert.Equal(t, category.Subscriptions[1].Feed.Name, importName(subscriptions[1].Title))
+}
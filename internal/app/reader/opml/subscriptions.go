@@ -0,0 +1,145 @@
+package opml
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+
+	"reader/internal/app/reader"
+	"reader/internal/app/reader/feeds/feeds"
+	"reader/internal/app/reader/models"
+	"reader/internal/pkg/utils"
+)
+
+const (
+	exportTitle      = "OnionReader subscriptions"
+	maxNameLength    = 255
+	maxFeedURLLength = 255
+)
+
+// ErrInvalidDocument is returned when the imported document cannot be decoded
+var ErrInvalidDocument = errors.New("invalid OPML document")
+
+// ImportResult import result
+type ImportResult struct {
+	Added    int // new subscriptions
+	Existing int // feeds already subscribed
+	Skipped  int // invalid subscriptions, e.g. URL too long
+}
+
+// Export exports subscriptions of user grouped by category as OPML document
+func Export(w io.Writer, userID int64) error {
+	categories, err := models.ListAllCategoriesWithFeeds(userID)
+	if err != nil {
+		return err
+	}
+
+	var categoryFeeds []*CategoryFeeds
+	for _, category := range categories {
+		if c := exportCategory(category); len(c.Feeds) > 0 {
+			categoryFeeds = append(categoryFeeds, c)
+		}
+	}
+
+	return Encode(w, exportTitle, categoryFeeds)
+}
+
+// exportCategory converts category with subscriptions to OPML folder, names are stored escaped
+func exportCategory(category *models.Category) *CategoryFeeds {
+	c := &CategoryFeeds{Name: html.UnescapeString(category.Name)}
+	for _, subscription := range category.Subscriptions {
+		c.Feeds = append(c.Feeds, &Subscription{
+			Title:   html.UnescapeString(subscription.Name()),
+			URL:     subscription.Feed.URL,
+			Website: subscription.Feed.Website,
+		})
+	}
+
+	return c
+}
+
+// importName converts name of OPML document to the stored form, names are stored escaped
+func importName(name string) string {
+	return utils.Truncate(html.EscapeString(name), maxNameLength)
+}
+
+// Import subscribes user to feeds of OPML document, folders become categories of user
+// and feeds outside any folder go to the shared uncategorized category
+func Import(r io.Reader, userID int64) (*ImportResult, error) {
+	doc, err := Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+
+	uncategorizedID, err := feeds.SetupCategory(models.SharedUserID, feeds.UncategorizedCategoryName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{}
+	categoryIDs := map[string]int64{"": uncategorizedID}
+
+	for _, subscription := range doc.Subscriptions() {
+		if len(subscription.URL) > maxFeedURLLength {
+			result.Skipped++
+			continue
+		}
+
+		title := importName(subscription.Title)
+
+		feedID, err := models.GetFeedIDForURL(subscription.URL)
+		if err != nil {
+			return nil, err
+		}
+		if feedID == -1 {
+			name := title
+			if name == "" {
+				name = importName(subscription.URL)
+			}
+
+			if feedID, err = models.AddFeed(
+				name,
+				int8(reader.PriorityMainStream),
+				subscription.URL,
+				utils.Truncate(subscription.Website, maxNameLength),
+				uncategorizedID,
+			); err != nil {
+				return nil, err
+			}
+			title = ""
+		}
+
+		existing, err := models.GetSubscription(userID, feedID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			result.Existing++
+			continue
+		}
+
+		categoryName := importName(subscription.Category)
+
+		categoryID, ok := categoryIDs[categoryName]
+		if !ok {
+			if categoryID, err = feeds.SetupCategory(userID, categoryName); err != nil {
+				return nil, err
+			}
+			categoryIDs[categoryName] = categoryID
+		}
+
+		if _, err := models.AddSubscription(
+			userID,
+			feedID,
+			categoryID,
+			int8(reader.PriorityMainStream),
+			title,
+		); err != nil {
+			return nil, err
+		}
+		result.Added++
+	}
+
+	return result, nil
+}
@@ -0,0 +1,164 @@
+package retention
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"reader/internal/app/reader/models"
+)
+
+const (
+	batchSize = 500
+	interval  = 6 * time.Hour
+)
+
+// Policy retention policy of feed entries, entries are purged when they are outside every set limit,
+// entries starred or tagged by any user are never purged
+type Policy struct {
+	Count int // newest entries kept, 0 keeps all
+	Days  int // days entries are kept, 0 keeps all
+}
+
+// Enabled returns true if policy purges entries
+func (p Policy) Enabled() bool {
+	return p.Count > 0 || p.Days > 0
+}
+
+// String returns the policy as text
+func (p Policy) String() string {
+	if !p.Enabled() {
+		return "keep all"
+	}
+
+	var limits []string
+	if p.Count > 0 {
+		limits = append(limits, fmt.Sprintf("newest %d", p.Count))
+	}
+	if p.Days > 0 {
+		limits = append(limits, fmt.Sprintf("%d days", p.Days))
+	}
+
+	return "keep " + strings.Join(limits, " or ")
+}
+
+// Report purge result of feed
+type Report struct {
+	FeedID   int64
+	FeedName string
+	Policy   Policy
+	Count    int64 // purged entries, or purgeable ones on dry run
+}
+
+// Global returns the global policy of RETENTION_COUNT and RETENTION_DAYS, invalid or unset values keep all
+func Global() Policy {
+	envInt := func(name string) int {
+		v, err := strconv.Atoi(os.Getenv(name))
+		if err != nil || v < 0 {
+			return 0
+		}
+		return v
+	}
+
+	return Policy{
+		Count: envInt("RETENTION_COUNT"),
+		Days:  envInt("RETENTION_DAYS"),
+	}
+}
+
+// FeedPolicy returns the policy of feed, unset settings of feed are taken from global
+func FeedPolicy(feed *models.Feed, global Policy) Policy {
+	policy := global
+	if feed.RetentionCount != nil {
+		policy.Count = int(*feed.RetentionCount)
+	}
+	if feed.RetentionDays != nil {
+		policy.Days = int(*feed.RetentionDays)
+	}
+
+	return policy
+}
+
+// Run purges entries of all feeds by their policies at now, nothing is purged on dry run,
+// returns the reports of feeds with purged entries
+func Run(now time.Time, dryRun bool) ([]*Report, error) {
+	feeds, err := models.ListFeeds()
+	if err != nil {
+		return nil, err
+	}
+
+	global := Global()
+	var reports []*Report
+	for _, feed := range feeds {
+		policy := FeedPolicy(feed, global)
+		if !policy.Enabled() {
+			continue
+		}
+
+		var before time.Time
+		if policy.Days > 0 {
+			before = now.AddDate(0, 0, -policy.Days)
+		}
+		ids, err := models.ListPurgeableEntryIDs(feed.ID, policy.Count, before)
+		if err != nil {
+			return reports, err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		report := &Report{
+			FeedID:   feed.ID,
+			FeedName: html.UnescapeString(feed.Name),
+			Policy:   policy,
+		}
+		if dryRun {
+			report.Count = int64(len(ids))
+		} else {
+			for i := 0; i < len(ids); i += batchSize {
+				end := i + batchSize
+				if end > len(ids) {
+					end = len(ids)
+				}
+
+				count, err := models.PurgeEntries(ids[i:end])
+				report.Count += count
+				if err != nil {
+					return append(reports, report), err
+				}
+			}
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// Start starts the periodic purge job
+func Start() {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			reports, err := Run(time.Now(), false)
+			for _, report := range reports {
+				log.WithFields(log.Fields{
+					"feed":  report.FeedName,
+					"count": report.Count,
+				}).Info("Purge entries")
+			}
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error": err,
+				}).Error("Purge entries")
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,36 @@
+package retention
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"reader/internal/app/reader/models"
+)
+
+func TestGlobal(t *testing.T) {
+	t.Setenv("RETENTION_COUNT", "500")
+	t.Setenv("RETENTION_DAYS", "")
+	assert.Equal(t, Policy{Count: 500}, Global())
+
+	t.Setenv("RETENTION_COUNT", "-1")
+	t.Setenv("RETENTION_DAYS", "30")
+	assert.Equal(t, Policy{Days: 30}, Global())
+}
+
+func TestFeedPolicy(t *testing.T) {
+	zero, days := int32(0), int32(7)
+	global := Policy{Count: 500, Days: 30}
+
+	assert.Equal(t, global, FeedPolicy(&models.Feed{}, global))
+	assert.Equal(t, Policy{Count: 500, Days: 7}, FeedPolicy(&models.Feed{RetentionDays: &days}, global))
+
+	policy := FeedPolicy(&models.Feed{RetentionCount: &zero, RetentionDays: &zero}, global)
+	assert.False(t, policy.Enabled())
+	assert.Equal(t, "keep all", policy.String())
+}
+
+func TestPolicyString(t *testing.T) {
+	assert.Equal(t, "keep newest 100", Policy{Count: 100}.String())
+	assert.Equal(t, "keep newest 100 or 30 days", Policy{Count: 100, Days: 30}.String())
+}
@@ -1,23 +1,40 @@
 package routes
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
 
 	"reader/internal/app/reader/models"
 	"reader/internal/pkg/routes"
 	"reader/internal/pkg/utils"
 )
 
+// AuthToken auth token information
+type AuthToken struct {
+	ID         int64  `json:"id"`
+	Current    bool   `json:"current"`
+	CreatedAt  int64  `json:"createdAt"`           // timestamp sec
+	ExpiresAt  *int64 `json:"expiresAt,omitempty"` // timestamp sec
+	Label      string `json:"label"`
+	LastUsedAt *int64 `json:"lastUsedAt,omitempty"` // timestamp sec
+	Scope      string `json:"scope"`
+}
+
 // Login client login binding
 type Login struct {
 	Email    string `form:"Email" binding:"required"`
 	Password string `form:"Passwd" binding:"required"`
+	Client   string `form:"client"`
+	Source   string `form:"source"`
 }
 
 // UserInfo user information
@@ -30,52 +47,174 @@ type UserInfo struct {
 
 const (
 	authPrefix = "GoogleLogin auth="
+
+	authTokenTouchInterval = time.Minute
+
+	defaultLoginAccountMaxFailures = 5
+	defaultLoginIPMaxFailures      = 20
+	defaultLoginLockoutWindow      = 15 * time.Minute
 )
 
+var (
+	// dummyPasswordHash is verified for unknown users so that they take as long as known users
+	dummyPasswordHash     string
+	dummyPasswordHashOnce sync.Once
+)
+
+// loginLimits returns the failed login limits per account and per IP within the lockout window
+func loginLimits() (accountMax, ipMax int64, window time.Duration) {
+	envInt := func(name string, def int64) int64 {
+		v, err := strconv.ParseInt(os.Getenv(name), 10, 64)
+		if err != nil || v <= 0 {
+			return def
+		}
+		return v
+	}
+
+	accountMax = envInt("LOGIN_ACCOUNT_MAX_FAILURES", defaultLoginAccountMaxFailures)
+	ipMax = envInt("LOGIN_IP_MAX_FAILURES", defaultLoginIPMaxFailures)
+
+	window, err := time.ParseDuration(os.Getenv("LOGIN_LOCKOUT_WINDOW"))
+	if err != nil || window <= 0 {
+		window = defaultLoginLockoutWindow
+	}
+
+	return accountMax, ipMax, window
+}
+
+// loginIPLocked returns true if IP of request reached the failed login limit
+func loginIPLocked(c *gin.Context) (bool, time.Duration, error) {
+	_, ipMax, window := loginLimits()
+
+	count, err := models.CountLoginFailuresForIP(c.ClientIP(), time.Now().Add(-window))
+	if err != nil {
+		return false, 0, err
+	}
+
+	return count >= ipMax, window, nil
+}
+
+// loginLocked returns true if email or IP of request reached the failed login limit
+func loginLocked(c *gin.Context, email string) (bool, time.Duration, error) {
+	locked, window, err := loginIPLocked(c)
+	if err != nil || locked {
+		return locked, window, err
+	}
+
+	accountMax, _, _ := loginLimits()
+	count, err := models.CountLoginFailuresForEmail(email, time.Now().Add(-window))
+	if err != nil {
+		return false, 0, err
+	}
+
+	return count >= accountMax, window, nil
+}
+
+// verifyLogin verifies password of user, it takes the same time if user is nil
+func verifyLogin(user *models.User, password string) bool {
+	if user == nil {
+		dummyPasswordHashOnce.Do(func() {
+			dummyPasswordHash, _ = utils.HashPassword(utils.RandomString(16))
+		})
+		utils.VerifyPassword(password, dummyPasswordHash)
+		return false
+	}
+
+	return utils.VerifyPassword(password, user.Password)
+}
+
+// recordAuthEvent records auth event with client information of request, failures are logged only
+func recordAuthEvent(c *gin.Context, event *models.AuthEvent) {
+	event.IP = c.ClientIP()
+	event.UserAgent = c.Request.UserAgent()
+	if err := models.AddAuthEvent(event); err != nil {
+		log.WithFields(log.Fields{
+			"event": event.Event,
+			"error": err,
+		}).Error("Record auth event")
+	}
+}
+
+// authTokenTTL returns the lifetime of issued auth tokens, 0 for never expires
+func authTokenTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("AUTH_TOKEN_TTL"))
+	if err != nil || ttl < 0 {
+		return 0
+	}
+
+	return ttl
+}
+
+// authenticateToken gets the valid auth token of scope for plain token and records its use, nil for invalid
+func authenticateToken(c *gin.Context, token, scope string) (*models.AuthToken, error) {
+	authToken, err := models.GetAuthToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if authToken == nil || authToken.User == nil || authToken.Scope != scope || authToken.Expired(now) {
+		return nil, nil
+	}
+
+	// token use is recorded at the same rate as it is touched
+	if authToken.LastUsedAt == nil || now.Sub(*authToken.LastUsedAt) >= authTokenTouchInterval {
+		if err := models.TouchAuthToken(authToken.ID, now); err != nil {
+			return nil, err
+		}
+		recordAuthEvent(c, &models.AuthEvent{
+			Event:       models.AuthEventTokenUse,
+			AuthTokenID: &authToken.ID,
+			UserID:      &authToken.UserID,
+		})
+	}
+
+	return authToken, nil
+}
+
 func checkAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// TODO: use c.Abort series functions
-
 		auth := c.Request.Header.Get("Authorization")
 		if !strings.HasPrefix(auth, authPrefix) {
-			c.JSON(routes.InvalidCredentialsError("Authorization header"))
+			c.AbortWithStatusJSON(routes.InvalidCredentialsError("Authorization header"))
 			return
 		}
 
-		sID := strings.TrimPrefix(auth, authPrefix)
-		s := strings.Split(sID, "/")
-		if len(s) != 2 {
-			c.JSON(routes.InvalidCredentialsError("Authorization header"))
+		authToken, err := authenticateToken(c, strings.TrimPrefix(auth, authPrefix), models.AuthTokenScopeFull)
+		if err != nil {
+			c.AbortWithStatusJSON(routes.InternalServerError())
 			return
 		}
-
-		user, err := models.GetUser(s[0])
-		if err != nil || user == nil {
-			c.JSON(routes.InvalidCredentialsError(""))
+		if authToken == nil {
+			c.AbortWithStatusJSON(routes.InvalidCredentialsError(""))
 			return
 		}
 
-		salt := os.Getenv("APP_SALT")
-		hash := utils.Sha1(fmt.Sprintf("%s%s%s", salt, user.Email, user.Password))
-		if s[1] != hash {
-			c.JSON(routes.InvalidCredentialsError(""))
-			return
-		}
-
-		c.Set("user", user)
+		c.Set("authToken", authToken)
+		c.Set("user", authToken.User)
 		c.Next()
 	}
 }
 
-func checkToken(user *models.User, token string) bool {
-	salt := os.Getenv("APP_SALT")
-	hash := utils.Sha1(fmt.Sprintf("%s%d%s", salt, user.ID, user.Password))
-	return token == utils.PadString(hash, "Z", 57, false)
+// contextAuthToken gets the auth token of request, the error response is written on failure
+func contextAuthToken(c *gin.Context) (*models.AuthToken, bool) {
+	authTokenData, ok := c.Get("authToken")
+	if !ok {
+		c.JSON(routes.InternalServerError())
+		return nil, false
+	}
+
+	return authTokenData.(*models.AuthToken), true
+}
+
+func checkToken(authToken *models.AuthToken, token string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(generateToken(authToken))) == 1
 }
 
-func generateToken(user *models.User) string {
+// generateToken generates the action token bound to auth token, so it is revoked along with the auth token
+func generateToken(authToken *models.AuthToken) string {
 	salt := os.Getenv("APP_SALT")
-	hash := utils.Sha1(fmt.Sprintf("%s%d%s", salt, user.ID, user.Password))
+	hash := utils.Sha1(fmt.Sprintf("%s%d%s", salt, authToken.ID, authToken.Hash))
 	return utils.PadString(hash, "Z", 57, false)
 }
 
@@ -86,34 +225,139 @@ func clientLogin(c *gin.Context) {
 		return
 	}
 
+	locked, retryAfter, err := loginLocked(c, login.Email)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if locked {
+		recordAuthEvent(c, &models.AuthEvent{
+			Event: models.AuthEventLoginLocked,
+			Email: login.Email,
+		})
+		c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+		c.JSON(routes.TooManyRequestsError())
+		return
+	}
+
 	user, err := models.GetUser(login.Email)
-	if err != nil || user == nil {
-		c.JSON(routes.InvalidCredentialsError(""))
+	if err != nil {
+		c.JSON(routes.InternalServerError())
 		return
 	}
-	if !utils.VerifyPassword(login.Password, user.Password) {
+	if !verifyLogin(user, login.Password) {
+		event := &models.AuthEvent{
+			Event: models.AuthEventLoginFailure,
+			Email: login.Email,
+		}
+		if user != nil {
+			event.UserID = &user.ID
+		}
+		recordAuthEvent(c, event)
 		c.JSON(routes.InvalidCredentialsError(""))
 		return
 	}
 
-	salt := os.Getenv("APP_SALT")
-	hash := utils.Sha1(fmt.Sprintf("%s%s%s", salt, user.Email, user.Password))
-	sid := fmt.Sprintf("%s/%s", user.Email, hash)
-	credentials := fmt.Sprintf("SID=%s\nLSID=null\nAuth=%s\n", sid, sid)
+	label := login.Client
+	if label == "" {
+		label = login.Source
+	}
+	if label == "" {
+		label = c.Request.UserAgent()
+	}
+
+	token, authToken, err := models.IssueAuthToken(user.ID, label, authTokenTTL())
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	recordAuthEvent(c, &models.AuthEvent{
+		Event:       models.AuthEventLogin,
+		Email:       login.Email,
+		AuthTokenID: &authToken.ID,
+		UserID:      &user.ID,
+	})
+
+	credentials := fmt.Sprintf("SID=%s\nLSID=null\nAuth=%s\n", token, token)
 
 	c.String(http.StatusOK, credentials)
 }
 
-func token(c *gin.Context) {
-	userData, ok := c.Get("user")
+func listAuthTokens(c *gin.Context) {
+	current, ok := contextAuthToken(c)
+	if !ok {
+		return
+	}
+
+	authTokens, err := models.ListAuthTokens(current.UserID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	unix := func(t *time.Time) *int64 {
+		if t == nil {
+			return nil
+		}
+		sec := t.Unix()
+		return &sec
+	}
+
+	list := []*AuthToken{}
+	for _, authToken := range authTokens {
+		list = append(list, &AuthToken{
+			ID:         authToken.ID,
+			Current:    authToken.ID == current.ID,
+			CreatedAt:  authToken.CreatedAt.Unix(),
+			ExpiresAt:  unix(authToken.ExpiresAt),
+			Label:      authToken.Label,
+			LastUsedAt: unix(authToken.LastUsedAt),
+			Scope:      authToken.Scope,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tokens": list,
+	})
+}
+
+func revokeAuthToken(c *gin.Context) {
+	bodyPosts, user, ok := parseCheckedPostBody(c)
 	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(firstPostValue(bodyPosts, "id"), 10, 64)
+	if err != nil {
+		c.JSON(routes.InvalidParameterError("id"))
+		return
+	}
+
+	count, err := models.DeleteAuthToken(user.ID, id)
+	if err != nil {
 		c.JSON(routes.InternalServerError())
 		return
 	}
+	if count == 0 {
+		c.JSON(routes.NotFoundError("token"))
+		return
+	}
+	recordAuthEvent(c, &models.AuthEvent{
+		Event:       models.AuthEventTokenRevoke,
+		AuthTokenID: &id,
+		UserID:      &user.ID,
+	})
 
-	token := generateToken(userData.(*models.User))
+	c.String(http.StatusOK, "OK")
+}
 
-	c.String(http.StatusOK, token)
+func token(c *gin.Context) {
+	authToken, ok := contextAuthToken(c)
+	if !ok {
+		return
+	}
+
+	c.String(http.StatusOK, generateToken(authToken))
 }
 
 func userInfo(c *gin.Context) {
@@ -133,14 +377,5 @@ func userInfo(c *gin.Context) {
 		Email:     user.Email,
 	}
 
-	output := c.Request.URL.Query().Get("output")
-	switch output {
-	case "":
-		fallthrough
-	case "json":
-		c.JSON(http.StatusOK, info)
-		return
-	default:
-		c.JSON(routes.InvalidParameterError("output"))
-	}
+	render(c, info)
 }
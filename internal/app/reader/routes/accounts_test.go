@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"reader/internal/app/reader/models"
+	"reader/internal/pkg/utils"
+)
+
+func TestLoginLimits(t *testing.T) {
+	t.Setenv("LOGIN_ACCOUNT_MAX_FAILURES", "")
+	t.Setenv("LOGIN_IP_MAX_FAILURES", "-1")
+	t.Setenv("LOGIN_LOCKOUT_WINDOW", "invalid")
+
+	accountMax, ipMax, window := loginLimits()
+	assert.Equal(t, int64(defaultLoginAccountMaxFailures), accountMax)
+	assert.Equal(t, int64(defaultLoginIPMaxFailures), ipMax)
+	assert.Equal(t, defaultLoginLockoutWindow, window)
+
+	t.Setenv("LOGIN_ACCOUNT_MAX_FAILURES", "3")
+	t.Setenv("LOGIN_IP_MAX_FAILURES", "50")
+	t.Setenv("LOGIN_LOCKOUT_WINDOW", "1h")
+
+	accountMax, ipMax, window = loginLimits()
+	assert.Equal(t, int64(3), accountMax)
+	assert.Equal(t, int64(50), ipMax)
+	assert.Equal(t, time.Hour, window)
+}
+
+func TestVerifyLogin(t *testing.T) {
+	hash, err := utils.HashPassword("secret")
+	assert.Nil(t, err)
+
+	user := &models.User{Password: hash}
+	assert.True(t, verifyLogin(user, "secret"))
+	assert.False(t, verifyLogin(user, "wrong"))
+	assert.False(t, verifyLogin(nil, "secret"))
+	assert.NotEmpty(t, dummyPasswordHash)
+}
@@ -0,0 +1,1081 @@
+package routes
+
+import (
+	_ "embed"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"reader/internal/app/reader"
+	"reader/internal/app/reader/feeds/feeds"
+	"reader/internal/app/reader/models"
+	"reader/internal/pkg/routes"
+	"reader/internal/pkg/utils"
+)
+
+const (
+	apiPrefix = "/api/v1"
+
+	apiDefaultEntryLimit = 50
+	apiMaxEntryLimit     = 200
+
+	bearerPrefix = "Bearer "
+)
+
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// APICategory REST API category
+type APICategory struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Shared      bool   `json:"shared"` // shared categories can not be modified
+	UnreadCount int64  `json:"unreadCount"`
+}
+
+// APICategoryInput REST API category create and update body
+type APICategoryInput struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// APIEntry REST API entry
+type APIEntry struct {
+	ID          int64     `json:"id"`
+	FeedID      int64     `json:"feedId"`
+	Author      string    `json:"author"`
+	Content     string    `json:"content"`
+	CreatedAt   time.Time `json:"createdAt"`
+	PublishedAt time.Time `json:"publishedAt"`
+	Read        bool      `json:"read"`
+	Starred     bool      `json:"starred"`
+	Tags        []string  `json:"tags"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+}
+
+// APIEntryStateInput REST API entry state update body, IDs are used for batch update only
+type APIEntryStateInput struct {
+	IDs     []int64 `json:"ids"`
+	Read    *bool   `json:"read"`
+	Starred *bool   `json:"starred"`
+}
+
+// APIFeed REST API feed subscribed by user
+type APIFeed struct {
+	ID            int64      `json:"id"`
+	CategoryID    int64      `json:"categoryId"`
+	LastError     string     `json:"lastError"`
+	LastSuccessAt *time.Time `json:"lastSuccessAt"`
+	Priority      int8       `json:"priority"`
+	SiteURL       string     `json:"siteUrl"`
+	Title         string     `json:"title"`
+	UnreadCount   int64      `json:"unreadCount"`
+	URL           string     `json:"url"`
+}
+
+// APIFeedInput REST API feed subscribe body
+type APIFeedInput struct {
+	CategoryID *int64 `json:"categoryId"`
+	Title      string `json:"title"`
+	URL        string `json:"url" binding:"required"`
+}
+
+// APIFeedUpdateInput REST API feed update body, empty title resets to the feed name
+type APIFeedUpdateInput struct {
+	CategoryID *int64  `json:"categoryId"`
+	Title      *string `json:"title"`
+}
+
+// APITag REST API tag
+type APITag struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	UnreadCount int64  `json:"unreadCount"`
+}
+
+// APITagInput REST API tag create and update body
+type APITagInput struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// APIUser REST API user
+type APIUser struct {
+	ID    int64  `json:"id"`
+	Email string `json:"email"`
+}
+
+func checkBearerAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth := c.Request.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, bearerPrefix) {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(routes.InvalidCredentialsError("Authorization header"))
+			return
+		}
+
+		authToken, err := authenticateToken(c, strings.TrimPrefix(auth, bearerPrefix), models.AuthTokenScopeFull)
+		if err != nil {
+			c.AbortWithStatusJSON(routes.InternalServerError())
+			return
+		}
+		if authToken == nil {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.AbortWithStatusJSON(routes.InvalidCredentialsError(""))
+			return
+		}
+
+		c.Set("authToken", authToken)
+		c.Set("user", authToken.User)
+		c.Next()
+	}
+}
+
+// bindAPIJSON binds JSON body, the error response is written on failure
+func bindAPIJSON(c *gin.Context, v interface{}) bool {
+	if err := c.ShouldBindJSON(v); err != nil {
+		c.JSON(routes.InvalidParameterError("body"))
+		return false
+	}
+
+	return true
+}
+
+// paramID parses ID path parameter, the error response is written on failure
+func paramID(c *gin.Context, key string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param(key), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(routes.InvalidParameterError(key))
+		return 0, false
+	}
+
+	return id, true
+}
+
+// unreadCountMap maps unread counts by ID
+func unreadCountMap(counts []*models.UnreadCount) map[int64]int64 {
+	m := make(map[int64]int64)
+	for _, count := range counts {
+		m[count.ID] = count.Count
+	}
+	return m
+}
+
+func apiFeed(subscription *models.Subscription, unreadCount int64) *APIFeed {
+	feed := subscription.Feed
+	return &APIFeed{
+		ID:            feed.ID,
+		CategoryID:    subscription.CategoryID,
+		LastError:     feed.LastError,
+		LastSuccessAt: feed.LastSuccessAt,
+		Priority:      subscription.Priority,
+		SiteURL:       html.UnescapeString(feed.Website),
+		Title:         html.UnescapeString(subscription.Name()),
+		UnreadCount:   unreadCount,
+		URL:           html.UnescapeString(feed.URL),
+	}
+}
+
+// apiEntries renders entries of IDs for user
+func apiEntries(user *models.User, ids []int64, asc bool) ([]*APIEntry, error) {
+	list := []*APIEntry{}
+	if len(ids) == 0 {
+		return list, nil
+	}
+
+	entries, err := models.ListEntriesByIDs(user.ID, ids, asc)
+	if err != nil {
+		return nil, err
+	}
+
+	entryStates, err := models.GetEntryStates(user.ID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	entryTagNames, err := models.GetTagNamesForEntryIDs(user.ID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		item := &APIEntry{
+			ID:          entry.ID,
+			FeedID:      entry.FeedID,
+			Author:      html.UnescapeString(entry.Author),
+			Content:     entry.Content,
+			CreatedAt:   entry.CreatedAt,
+			PublishedAt: entry.Date,
+			Tags:        []string{},
+			Title:       html.UnescapeString(entry.Title),
+			URL:         html.UnescapeString(entry.Link),
+		}
+		if state, ok := entryStates[entry.ID]; ok {
+			item.Read = state.Read
+			item.Starred = state.Starred
+		}
+		for _, tagName := range entryTagNames[entry.ID] {
+			item.Tags = append(item.Tags, html.UnescapeString(tagName))
+		}
+		list = append(list, item)
+	}
+
+	return list, nil
+}
+
+// markAPIEntries applies state changes to entries of user
+func markAPIEntries(user *models.User, ids []int64, input *APIEntryStateInput) error {
+	if input.Read != nil {
+		if _, err := models.MarkRead(user.ID, ids, *input.Read); err != nil {
+			return err
+		}
+	}
+	if input.Starred != nil {
+		if _, err := models.MarkStarred(user.ID, ids, *input.Starred); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func openAPI(c *gin.Context) {
+	c.Data(http.StatusOK, "application/yaml; charset=utf-8", openAPISpec)
+}
+
+func apiMe(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, &APIUser{
+		ID:    user.ID,
+		Email: user.Email,
+	})
+}
+
+func apiListCategories(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	categories, err := models.ListCategories(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	counts, err := models.CountUnreadByCategory(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	unreadCounts := unreadCountMap(counts)
+
+	list := []*APICategory{}
+	for _, category := range categories {
+		list = append(list, &APICategory{
+			ID:          category.ID,
+			Name:        html.UnescapeString(category.Name),
+			Shared:      category.UserID == models.SharedUserID,
+			UnreadCount: unreadCounts[category.ID],
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"categories": list,
+	})
+}
+
+func apiCreateCategory(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	var input APICategoryInput
+	if !bindAPIJSON(c, &input) {
+		return
+	}
+	name := html.EscapeString(strings.TrimSpace(input.Name))
+	if name == "" || len(name) > 255 {
+		c.JSON(routes.InvalidParameterError("name"))
+		return
+	}
+
+	category, err := models.GetCategoryForName(user.ID, name)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if category != nil {
+		c.JSON(routes.ConflictError("category"))
+		return
+	}
+
+	id, err := models.AddCategory(user.ID, name)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("%s/categories/%d", apiPrefix, id))
+	c.JSON(http.StatusCreated, &APICategory{
+		ID:   id,
+		Name: html.UnescapeString(name),
+	})
+}
+
+func apiUpdateCategory(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var input APICategoryInput
+	if !bindAPIJSON(c, &input) {
+		return
+	}
+	name := html.EscapeString(strings.TrimSpace(input.Name))
+	if name == "" || len(name) > 255 {
+		c.JSON(routes.InvalidParameterError("name"))
+		return
+	}
+
+	category, err := models.GetCategory(user.ID, id)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if category == nil {
+		c.JSON(routes.NotFoundError("category"))
+		return
+	}
+	if category.UserID == models.SharedUserID {
+		c.JSON(routes.ForbiddenError("category"))
+		return
+	}
+
+	existing, err := models.GetCategoryForName(user.ID, name)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if existing != nil && existing.ID != id {
+		c.JSON(routes.ConflictError("category"))
+		return
+	}
+
+	if err := models.RenameCategory(user.ID, id, name); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.JSON(http.StatusOK, &APICategory{
+		ID:   id,
+		Name: html.UnescapeString(name),
+	})
+}
+
+func apiDeleteCategory(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	category, err := models.GetCategory(user.ID, id)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if category == nil {
+		c.JSON(routes.NotFoundError("category"))
+		return
+	}
+	if category.UserID == models.SharedUserID {
+		c.JSON(routes.ForbiddenError("category"))
+		return
+	}
+
+	uncategorizedID, err := feeds.SetupCategory(models.SharedUserID, feeds.UncategorizedCategoryName)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if err := models.DeleteCategory(id, uncategorizedID); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func apiListEntries(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	query := c.Request.URL.Query()
+	parseInt := func(key string) (int64, bool) {
+		id, err := strconv.ParseInt(query.Get(key), 10, 64)
+		if err != nil {
+			c.JSON(routes.InvalidParameterError(key))
+			return 0, false
+		}
+		return id, true
+	}
+	parseBool := func(key string) (bool, bool) {
+		v, err := strconv.ParseBool(query.Get(key))
+		if err != nil {
+			c.JSON(routes.InvalidParameterError(key))
+			return false, false
+		}
+		return v, true
+	}
+
+	scopes := []func(*gorm.DB) *gorm.DB{models.UserScope(user.ID)}
+	switch {
+	case query.Has("feedId"):
+		id, ok := parseInt("feedId")
+		if !ok {
+			return
+		}
+		scopes = append(scopes, models.FeedScope(id))
+	case query.Has("categoryId"):
+		id, ok := parseInt("categoryId")
+		if !ok {
+			return
+		}
+		scopes = append(scopes, models.CategoryScope(id))
+	case query.Has("tagId"):
+		id, ok := parseInt("tagId")
+		if !ok {
+			return
+		}
+		tag, err := models.GetTag(user.ID, id)
+		if err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+		if tag == nil {
+			c.JSON(routes.NotFoundError("tag"))
+			return
+		}
+		scopes = append(scopes, models.TagScope(id))
+	default:
+		scopes = append(scopes, models.AllScope)
+	}
+
+	state := reader.State(reader.StateAll | reader.StateFavorite | reader.StateNotFavorite)
+	if query.Has("read") {
+		read, ok := parseBool("read")
+		if !ok {
+			return
+		}
+		if read {
+			state &^= reader.StateNotRead
+		} else {
+			state &^= reader.StateRead
+		}
+	}
+	if query.Has("starred") {
+		starred, ok := parseBool("starred")
+		if !ok {
+			return
+		}
+		if starred {
+			state &^= reader.StateNotFavorite
+		} else {
+			state &^= reader.StateFavorite
+		}
+	}
+	scopes = append(scopes, models.StateScope(state))
+	if q := strings.TrimSpace(query.Get("q")); q != "" {
+		scopes = append(scopes, models.SearchScope(q))
+	}
+
+	var asc bool
+	switch query.Get("order") {
+	case "", "desc":
+	case "asc":
+		asc = true
+	default:
+		c.JSON(routes.InvalidParameterError("order"))
+		return
+	}
+	scopes = append(scopes, models.OrderScope(asc))
+
+	// cursor is the ID of the last entry of previous page
+	if query.Has("cursor") {
+		cursor, ok := parseInt("cursor")
+		if !ok {
+			return
+		}
+		scopes = append(scopes, models.ContinuationScope(cursor, asc))
+	}
+
+	limit := apiDefaultEntryLimit
+	if query.Has("limit") {
+		n, err := strconv.Atoi(query.Get("limit"))
+		if err != nil || n <= 0 || n > apiMaxEntryLimit {
+			c.JSON(routes.InvalidParameterError("limit"))
+			return
+		}
+		limit = n
+	}
+	scopes = append(scopes, models.CountScope(limit))
+
+	ids, count, err := models.ListEntryIDs(scopes...)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	entries, err := apiEntries(user, ids, asc)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	res := gin.H{
+		"entries": entries,
+	}
+	if count > len(ids) {
+		res["nextCursor"] = strconv.FormatInt(ids[len(ids)-1], 10)
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func apiGetEntry(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	entries, err := apiEntries(user, []int64{id}, true)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if len(entries) == 0 {
+		c.JSON(routes.NotFoundError("entry"))
+		return
+	}
+
+	c.JSON(http.StatusOK, entries[0])
+}
+
+func apiUpdateEntry(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var input APIEntryStateInput
+	if !bindAPIJSON(c, &input) {
+		return
+	}
+
+	entries, err := models.ListEntriesByIDs(user.ID, []int64{id}, true)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if len(entries) == 0 {
+		c.JSON(routes.NotFoundError("entry"))
+		return
+	}
+
+	if err := markAPIEntries(user, []int64{id}, &input); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	list, err := apiEntries(user, []int64{id}, true)
+	if err != nil || len(list) == 0 {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.JSON(http.StatusOK, list[0])
+}
+
+func apiUpdateEntries(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	var input APIEntryStateInput
+	if !bindAPIJSON(c, &input) {
+		return
+	}
+	if len(input.IDs) == 0 {
+		c.JSON(routes.InvalidParameterError("ids"))
+		return
+	}
+
+	if err := markAPIEntries(user, input.IDs, &input); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// apiEditEntryTag adds or removes tag of entry
+func apiEditEntryTag(add bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := contextUser(c)
+		if !ok {
+			return
+		}
+
+		id, ok := paramID(c, "id")
+		if !ok {
+			return
+		}
+		tagID, ok := paramID(c, "tagId")
+		if !ok {
+			return
+		}
+
+		entries, err := models.ListEntriesByIDs(user.ID, []int64{id}, true)
+		if err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+		if len(entries) == 0 {
+			c.JSON(routes.NotFoundError("entry"))
+			return
+		}
+
+		tag, err := models.GetTag(user.ID, tagID)
+		if err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+		if tag == nil {
+			c.JSON(routes.NotFoundError("tag"))
+			return
+		}
+
+		if add {
+			err = models.AddTagForEntries(tag.ID, []int64{id})
+		} else {
+			err = models.RemoveTagForEntries(tag.ID, []int64{id})
+		}
+		if err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func apiListFeeds(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	categories, err := models.ListAllCategoriesWithFeeds(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	counts, err := models.CountUnreadByFeed(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	unreadCounts := unreadCountMap(counts)
+
+	list := []*APIFeed{}
+	for _, category := range categories {
+		for _, subscription := range category.Subscriptions {
+			list = append(list, apiFeed(subscription, unreadCounts[subscription.FeedID]))
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"feeds": list,
+	})
+}
+
+// apiSubscriptionFeed writes the feed subscribed by user with status
+func apiSubscriptionFeed(c *gin.Context, user *models.User, feedID int64, status int) {
+	subscription, err := models.GetSubscription(user.ID, feedID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if subscription == nil {
+		c.JSON(routes.NotFoundError("feed"))
+		return
+	}
+
+	counts, err := models.CountUnreadByFeed(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.JSON(status, apiFeed(subscription, unreadCountMap(counts)[feedID]))
+}
+
+// apiCheckCategory checks the category of ID is visible to user, the error response is written on failure
+func apiCheckCategory(c *gin.Context, user *models.User, id int64) bool {
+	category, err := models.GetCategory(user.ID, id)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return false
+	}
+	if category == nil {
+		c.JSON(routes.NotFoundError("category"))
+		return false
+	}
+
+	return true
+}
+
+func apiCreateFeed(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	var input APIFeedInput
+	if !bindAPIJSON(c, &input) {
+		return
+	}
+
+	u, err := url.Parse(input.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(input.URL) > 255 {
+		c.JSON(routes.InvalidParameterError("url"))
+		return
+	}
+	if input.CategoryID != nil && !apiCheckCategory(c, user, *input.CategoryID) {
+		return
+	}
+
+	feedID, err := models.GetFeedIDForURL(input.URL)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if feedID != -1 {
+		subscription, err := models.GetSubscription(user.ID, feedID)
+		if err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+		if subscription != nil {
+			c.JSON(routes.ConflictError("feed"))
+			return
+		}
+	}
+
+	if feedID, err = subscribeFeed(user, input.URL, input.Title, ""); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if input.Title != "" {
+		if err := models.UpdateSubscriptionTitle(user.ID, feedID, utils.Truncate(html.EscapeString(input.Title), 255)); err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+	}
+	if input.CategoryID != nil {
+		if err := models.UpdateSubscriptionCategory(user.ID, feedID, *input.CategoryID); err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+	}
+
+	c.Header("Location", fmt.Sprintf("%s/feeds/%d", apiPrefix, feedID))
+	apiSubscriptionFeed(c, user, feedID, http.StatusCreated)
+}
+
+func apiGetFeed(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	apiSubscriptionFeed(c, user, id, http.StatusOK)
+}
+
+func apiUpdateFeed(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var input APIFeedUpdateInput
+	if !bindAPIJSON(c, &input) {
+		return
+	}
+
+	subscription, err := models.GetSubscription(user.ID, id)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if subscription == nil {
+		c.JSON(routes.NotFoundError("feed"))
+		return
+	}
+
+	if input.CategoryID != nil {
+		if !apiCheckCategory(c, user, *input.CategoryID) {
+			return
+		}
+		if err := models.UpdateSubscriptionCategory(user.ID, id, *input.CategoryID); err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+	}
+	if input.Title != nil {
+		title := utils.Truncate(html.EscapeString(strings.TrimSpace(*input.Title)), 255)
+		if err := models.UpdateSubscriptionTitle(user.ID, id, title); err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+	}
+
+	apiSubscriptionFeed(c, user, id, http.StatusOK)
+}
+
+func apiDeleteFeed(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	subscription, err := models.GetSubscription(user.ID, id)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if subscription == nil {
+		c.JSON(routes.NotFoundError("feed"))
+		return
+	}
+
+	if err := unsubscribeFeed(user, id); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func apiListTags(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	tags, err := models.ListTags(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	counts, err := models.CountUnreadByTag(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	unreadCounts := unreadCountMap(counts)
+
+	list := []*APITag{}
+	for _, tag := range tags {
+		list = append(list, &APITag{
+			ID:          tag.ID,
+			Name:        html.UnescapeString(tag.Name),
+			UnreadCount: unreadCounts[tag.ID],
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tags": list,
+	})
+}
+
+// apiTagName parses the escaped tag name of input, the error response is written on failure
+func apiTagName(c *gin.Context, input *APITagInput) (string, bool) {
+	name := html.EscapeString(strings.TrimSpace(input.Name))
+	if name == "" || len(name) > 63 {
+		c.JSON(routes.InvalidParameterError("name"))
+		return "", false
+	}
+
+	return name, true
+}
+
+func apiCreateTag(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	var input APITagInput
+	if !bindAPIJSON(c, &input) {
+		return
+	}
+	name, ok := apiTagName(c, &input)
+	if !ok {
+		return
+	}
+
+	tagID, err := models.GetTagIDForName(user.ID, name)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if tagID != -1 {
+		c.JSON(routes.ConflictError("tag"))
+		return
+	}
+
+	id, err := models.AddTag(user.ID, name)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("%s/tags/%d", apiPrefix, id))
+	c.JSON(http.StatusCreated, &APITag{
+		ID:   id,
+		Name: html.UnescapeString(name),
+	})
+}
+
+func apiUpdateTag(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var input APITagInput
+	if !bindAPIJSON(c, &input) {
+		return
+	}
+	name, ok := apiTagName(c, &input)
+	if !ok {
+		return
+	}
+
+	tag, err := models.GetTag(user.ID, id)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if tag == nil {
+		c.JSON(routes.NotFoundError("tag"))
+		return
+	}
+
+	tagID, err := models.GetTagIDForName(user.ID, name)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if tagID != -1 && tagID != id {
+		c.JSON(routes.ConflictError("tag"))
+		return
+	}
+
+	if err := models.RenameTag(user.ID, id, name); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.JSON(http.StatusOK, &APITag{
+		ID:   id,
+		Name: html.UnescapeString(name),
+	})
+}
+
+func apiDeleteTag(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	tag, err := models.GetTag(user.ID, id)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if tag == nil {
+		c.JSON(routes.NotFoundError("tag"))
+		return
+	}
+
+	if err := models.DeleteTag(user.ID, id); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
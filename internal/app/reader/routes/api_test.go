@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestOpenAPISpecCoversRoutes(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]interface{} `yaml:"paths"`
+	}
+	assert.Nil(t, yaml.Unmarshal(openAPISpec, &spec))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	SetupRoutes(router)
+
+	param := regexp.MustCompile(`:(\w+)`)
+	for _, route := range router.Routes() {
+		if !strings.HasPrefix(route.Path, apiPrefix+"/") || route.Path == apiPrefix+"/openapi.yaml" {
+			continue
+		}
+
+		path := param.ReplaceAllString(strings.TrimPrefix(route.Path, apiPrefix), "{$1}")
+		operations, ok := spec.Paths[path]
+		if assert.True(t, ok, path) {
+			assert.Contains(t, operations, strings.ToLower(route.Method), path)
+		}
+	}
+}
+
+func TestAPIBearerAuthMissing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	SetupRoutes(router)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/me", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/openapi.yaml", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Body.String(), "openapi: 3.0.3"))
+}
@@ -0,0 +1,190 @@
+package routes
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+
+	"reader/internal/app/reader/events"
+	"reader/internal/app/reader/models"
+	"reader/internal/pkg/routes"
+	"reader/internal/pkg/utils"
+)
+
+const (
+	eventsKeepAliveInterval = 30 * time.Second
+	eventsRetry             = 5000 // milliseconds
+	eventsTicketBytes       = 32
+	eventsTicketTTL         = time.Minute
+)
+
+// EventsTicket one-time ticket opening the event stream, since browser EventSource can not set Authorization header
+type EventsTicket struct {
+	Ticket    string `json:"ticket"`
+	ExpiresAt int64  `json:"expiresAt"` // timestamp sec
+}
+
+type eventsTicket struct {
+	authToken *models.AuthToken
+	expiresAt time.Time
+}
+
+var (
+	eventsTickets      = make(map[string]*eventsTicket) // keyed by sha256 of ticket
+	eventsTicketsMutex sync.Mutex
+)
+
+// issueEventsTicket issues one-time ticket for auth token
+func issueEventsTicket(authToken *models.AuthToken, now time.Time) (string, time.Time, error) {
+	ticket, err := utils.SecureRandomHex(eventsTicketBytes)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := now.Add(eventsTicketTTL)
+
+	eventsTicketsMutex.Lock()
+	defer eventsTicketsMutex.Unlock()
+
+	for hash, t := range eventsTickets {
+		if !now.Before(t.expiresAt) {
+			delete(eventsTickets, hash)
+		}
+	}
+	eventsTickets[utils.Sha256(ticket)] = &eventsTicket{authToken: authToken, expiresAt: expiresAt}
+
+	return ticket, expiresAt, nil
+}
+
+// redeemEventsTicket redeems ticket, returns the auth token it was issued for, nil for invalid or expired
+func redeemEventsTicket(ticket string, now time.Time) *models.AuthToken {
+	hash := utils.Sha256(ticket)
+
+	eventsTicketsMutex.Lock()
+	defer eventsTicketsMutex.Unlock()
+
+	t, ok := eventsTickets[hash]
+	if !ok {
+		return nil
+	}
+	delete(eventsTickets, hash)
+	if !now.Before(t.expiresAt) || t.authToken.Expired(now) {
+		return nil
+	}
+
+	return t.authToken
+}
+
+// checkEventsAuth authenticates the event stream by ticket query parameter, or bearer auth without it
+func checkEventsAuth() gin.HandlerFunc {
+	bearerAuth := checkBearerAuth()
+
+	return func(c *gin.Context) {
+		ticket, ok := c.GetQuery("ticket")
+		if !ok {
+			bearerAuth(c)
+			return
+		}
+
+		authToken := redeemEventsTicket(ticket, time.Now())
+		if authToken == nil {
+			c.AbortWithStatusJSON(routes.InvalidCredentialsError("ticket"))
+			return
+		}
+
+		c.Set("authToken", authToken)
+		c.Set("user", authToken.User)
+		c.Next()
+	}
+}
+
+func apiCreateEventsTicket(c *gin.Context) {
+	authToken, ok := contextAuthToken(c)
+	if !ok {
+		return
+	}
+
+	ticket, expiresAt, err := issueEventsTicket(authToken, time.Now())
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.JSON(http.StatusCreated, &EventsTicket{
+		Ticket:    ticket,
+		ExpiresAt: expiresAt.Unix(),
+	})
+}
+
+// apiEvents streams entry events of user as server-sent events, missed events are sent first on resume,
+// or a reset event if they are unavailable
+func apiEvents(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("lastEventId")
+	}
+
+	subscriber, missed, resumed := events.Subscribe(user.ID, lastEventID)
+	defer events.Unsubscribe(subscriber)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	write := func(event *events.Event) error {
+		return sse.Encode(c.Writer, sse.Event{
+			Id:    event.ID,
+			Event: event.Type,
+			Data:  event,
+		})
+	}
+
+	if _, err := io.WriteString(c.Writer, fmt.Sprintf("retry: %d\n\n", eventsRetry)); err != nil {
+		return
+	}
+	if !resumed {
+		if err := sse.Encode(c.Writer, sse.Event{Event: "reset", Data: gin.H{}}); err != nil {
+			return
+		}
+	}
+	for _, event := range missed {
+		if err := write(event); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(eventsKeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-subscriber.Events:
+			if !ok {
+				// dropped for falling behind, the client resumes after reconnecting
+				return
+			}
+			if err := write(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := io.WriteString(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
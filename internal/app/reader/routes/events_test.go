@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"reader/internal/app/reader/models"
+)
+
+func TestEventsTicket(t *testing.T) {
+	now := time.Now()
+	authToken := &models.AuthToken{ID: 1, User: &models.User{ID: 1}}
+
+	ticket, expiresAt, err := issueEventsTicket(authToken, now)
+	assert.Nil(t, err)
+	assert.Equal(t, now.Add(eventsTicketTTL), expiresAt)
+	assert.Equal(t, authToken, redeemEventsTicket(ticket, now))
+	assert.Nil(t, redeemEventsTicket(ticket, now))
+
+	ticket, _, err = issueEventsTicket(authToken, now)
+	assert.Nil(t, err)
+	assert.Nil(t, redeemEventsTicket(ticket, now.Add(eventsTicketTTL)))
+	assert.Nil(t, redeemEventsTicket("", now))
+}
+
+func TestEventsTicketInvalid(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	SetupRoutes(router)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/events?ticket=invalid", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/events", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+}
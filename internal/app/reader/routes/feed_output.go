@@ -0,0 +1,556 @@
+package routes
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"reader/internal/app/reader/models"
+	"reader/internal/pkg/routes"
+	"reader/internal/pkg/utils"
+)
+
+const (
+	outputDefaultCount = 50
+	outputMaxCount     = 200
+	outputTokenBytes   = 24
+)
+
+// content types of feed output formats, the format is the extension of output file
+var outputContentTypes = map[string]string{
+	"atom": "application/atom+xml; charset=utf-8",
+	"json": "application/feed+json; charset=utf-8",
+	"rss":  "application/rss+xml; charset=utf-8",
+}
+
+// APIOutput REST API feed output of stream, URLs are returned on creation only
+type APIOutput struct {
+	ID        int64             `json:"id"`
+	CreatedAt time.Time         `json:"createdAt"`
+	StreamID  string            `json:"streamId"`
+	URLs      map[string]string `json:"urls,omitempty"` // by format
+}
+
+// APIOutputInput REST API feed output create body
+type APIOutputInput struct {
+	StreamID string `json:"streamId" binding:"required"`
+}
+
+// outputStream stream of feed output
+type outputStream struct {
+	ID     string // canonical stream ID
+	Link   string // home page, empty for the reader
+	Scopes []func(*gorm.DB) *gorm.DB
+	Title  string
+}
+
+// outputItem entry of feed output, texts are unescaped
+type outputItem struct {
+	ID        string
+	Author    string
+	Content   string
+	FeedTitle string
+	Link      string
+	Published time.Time
+	Title     string
+}
+
+// outputFeed feed output of stream
+type outputFeed struct {
+	HomeURL string
+	Items   []*outputItem
+	SelfURL string
+	Title   string
+	Updated time.Time
+}
+
+type outputAtomContent struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+type outputAtomEntry struct {
+	ID        string            `xml:"id"`
+	Title     outputAtomContent `xml:"title"`
+	Links     []*atomLink       `xml:"link"`
+	Published string            `xml:"published"`
+	Updated   string            `xml:"updated"`
+	Author    *atomPerson       `xml:"author,omitempty"`
+	Content   outputAtomContent `xml:"content"`
+	Source    *outputAtomSource `xml:"source,omitempty"`
+}
+
+type outputAtomFeed struct {
+	XMLName   xml.Name           `xml:"feed"`
+	XMLNS     string             `xml:"xmlns,attr"`
+	ID        string             `xml:"id"`
+	Title     string             `xml:"title"`
+	Updated   string             `xml:"updated"`
+	Generator string             `xml:"generator"`
+	Author    *atomPerson        `xml:"author"`
+	Links     []*atomLink        `xml:"link"`
+	Entries   []*outputAtomEntry `xml:"entry"`
+}
+
+type outputAtomSource struct {
+	Title string `xml:"title"`
+}
+
+type outputRSSChannel struct {
+	Title         string           `xml:"title"`
+	Link          string           `xml:"link"`
+	Description   string           `xml:"description"`
+	Generator     string           `xml:"generator"`
+	LastBuildDate string           `xml:"lastBuildDate"`
+	AtomLink      *atomLink        `xml:"atom:link"`
+	Items         []*outputRSSItem `xml:"item"`
+}
+
+type outputRSSFeed struct {
+	XMLName   xml.Name          `xml:"rss"`
+	Version   string            `xml:"version,attr"`
+	XMLNSAtom string            `xml:"xmlns:atom,attr"`
+	XMLNSDC   string            `xml:"xmlns:dc,attr"`
+	Channel   *outputRSSChannel `xml:"channel"`
+}
+
+type outputRSSGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Text        string `xml:",chardata"`
+}
+
+type outputRSSItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link,omitempty"`
+	GUID        outputRSSGUID `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Creator     string        `xml:"dc:creator,omitempty"`
+	Description string        `xml:"description"`
+}
+
+type outputJSONAuthor struct {
+	Name string `json:"name"`
+}
+
+type outputJSONFeed struct {
+	Version     string            `json:"version"`
+	Title       string            `json:"title"`
+	HomePageURL string            `json:"home_page_url,omitempty"`
+	FeedURL     string            `json:"feed_url"`
+	Items       []*outputJSONItem `json:"items"`
+}
+
+type outputJSONItem struct {
+	ID            string              `json:"id"`
+	URL           string              `json:"url,omitempty"`
+	Title         string              `json:"title"`
+	ContentHTML   string              `json:"content_html"`
+	DatePublished string              `json:"date_published"`
+	Authors       []*outputJSONAuthor `json:"authors,omitempty"`
+}
+
+func (f *outputFeed) atom() *outputAtomFeed {
+	feed := &outputAtomFeed{
+		XMLNS:     "http://www.w3.org/2005/Atom",
+		ID:        f.SelfURL,
+		Title:     f.Title,
+		Updated:   atomTime(f.Updated),
+		Generator: "OnionReader",
+		Author:    &atomPerson{Name: "OnionReader"},
+		Links: []*atomLink{
+			{Rel: "self", Href: f.SelfURL, Type: "application/atom+xml"},
+		},
+	}
+	if f.HomeURL != "" {
+		feed.Links = append(feed.Links, &atomLink{Rel: "alternate", Href: f.HomeURL, Type: "text/html"})
+	}
+
+	for _, item := range f.Items {
+		entry := &outputAtomEntry{
+			ID:        item.ID,
+			Title:     outputAtomContent{Type: "text", Text: item.Title},
+			Published: atomTime(item.Published),
+			Updated:   atomTime(item.Published),
+			Content:   outputAtomContent{Type: "html", Text: item.Content},
+			Source:    &outputAtomSource{Title: item.FeedTitle},
+		}
+		if item.Link != "" {
+			entry.Links = append(entry.Links, &atomLink{Rel: "alternate", Href: item.Link, Type: "text/html"})
+		}
+		if item.Author != "" {
+			entry.Author = &atomPerson{Name: item.Author}
+		}
+
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return feed
+}
+
+func (f *outputFeed) jsonFeed() *outputJSONFeed {
+	feed := &outputJSONFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.HomeURL,
+		FeedURL:     f.SelfURL,
+		Items:       []*outputJSONItem{},
+	}
+
+	for _, item := range f.Items {
+		jsonItem := &outputJSONItem{
+			ID:            item.ID,
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentHTML:   item.Content,
+			DatePublished: item.Published.UTC().Format(time.RFC3339),
+		}
+		if item.Author != "" {
+			jsonItem.Authors = []*outputJSONAuthor{{Name: item.Author}}
+		}
+
+		feed.Items = append(feed.Items, jsonItem)
+	}
+
+	return feed
+}
+
+func (f *outputFeed) rss() *outputRSSFeed {
+	channel := &outputRSSChannel{
+		Title:         f.Title,
+		Link:          f.HomeURL,
+		Description:   f.Title,
+		Generator:     "OnionReader",
+		LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+		AtomLink:      &atomLink{Rel: "self", Href: f.SelfURL, Type: "application/rss+xml"},
+	}
+	if channel.Link == "" {
+		channel.Link = f.SelfURL
+	}
+
+	for _, item := range f.Items {
+		channel.Items = append(channel.Items, &outputRSSItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        outputRSSGUID{Text: item.ID},
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+			Creator:     item.Author,
+			Description: item.Content,
+		})
+	}
+
+	return &outputRSSFeed{
+		Version:   "2.0",
+		XMLNSAtom: "http://www.w3.org/2005/Atom",
+		XMLNSDC:   "http://purl.org/dc/elements/1.1/",
+		Channel:   channel,
+	}
+}
+
+// outputBaseURL returns the public base URL of the reader from APP_URL, false if it is unset or invalid,
+// the request host is never used since it is controlled by the client
+func outputBaseURL() (string, bool) {
+	v := strings.TrimRight(strings.TrimSpace(os.Getenv("APP_URL")), "/")
+	u, err := url.Parse(v)
+	if v == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", false
+	}
+
+	return v, true
+}
+
+// contextOutputBaseURL returns the public base URL of the reader, the error response is written if it is not configured
+func contextOutputBaseURL(c *gin.Context) (string, bool) {
+	baseURL, ok := outputBaseURL()
+	if !ok {
+		log.Error("APP_URL must be set to an http or https URL for feed outputs")
+		c.JSON(routes.NotConfiguredError("APP_URL"))
+		return "", false
+	}
+
+	return baseURL, true
+}
+
+// outputURLs returns the feed output URLs of token by format
+func outputURLs(baseURL, token string) map[string]string {
+	urls := make(map[string]string, len(outputContentTypes))
+	for format := range outputContentTypes {
+		urls[format] = fmt.Sprintf("%s/output/%s.%s", baseURL, token, format)
+	}
+
+	return urls
+}
+
+// resolveOutputStream resolves stream ID of user with its title and home page, nil for streams that do not exist
+func resolveOutputStream(user *models.User, streamID string) (*outputStream, error) {
+	if name := parseLabel(user, streamID); name != "" {
+		streamID = "user/-/label/" + name
+	}
+
+	scopes, err := streamScopes(user, streamID)
+	if err != nil || scopes == nil {
+		return nil, err
+	}
+	stream := &outputStream{
+		ID:     streamID,
+		Scopes: scopes,
+	}
+
+	switch {
+	case streamID == "user/-/state/com.google/reading-list":
+		stream.Title = "Reading list"
+	case streamID == "user/-/state/com.google/starred":
+		stream.Title = "Starred"
+	case strings.HasPrefix(streamID, "feed/"):
+		feedID, err := parseFeedStreamID(streamID[5:])
+		if err != nil {
+			return nil, err
+		}
+
+		subscription, err := models.GetSubscription(user.ID, feedID)
+		if err != nil || subscription == nil {
+			return nil, err
+		}
+		stream.ID = fmt.Sprintf("feed/%d", feedID)
+		stream.Link = html.UnescapeString(subscription.Feed.Website)
+		stream.Title = html.UnescapeString(subscription.Name())
+	default:
+		stream.Title = streamID[13:]
+	}
+
+	return stream, nil
+}
+
+// outputItems renders entries as feed output items of user
+func outputItems(user *models.User, entries []*models.Entry) ([]*outputItem, error) {
+	feedCategoryNames, err := models.GetFeedAndCategoryNames(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*outputItem
+	for _, entry := range entries {
+		feedTitle := ""
+		if names, ok := feedCategoryNames[entry.FeedID]; ok {
+			feedTitle = html.UnescapeString(names.FeedName)
+		}
+
+		items = append(items, &outputItem{
+			ID: googleReaderItemPrefix + "item/" +
+				utils.PadString(strconv.FormatInt(entry.ID, 16), "0", 16, true),
+			Author:    html.UnescapeString(entry.Author),
+			Content:   entry.Content,
+			FeedTitle: feedTitle,
+			Link:      html.UnescapeString(entry.Link),
+			Published: entry.Date,
+			Title:     html.UnescapeString(entry.Title),
+		})
+	}
+
+	return items, nil
+}
+
+// renderOutput renders feed output of stream as RSS 2.0, Atom 1.0 or JSON Feed 1.1,
+// the output is read with token in the file name, e.g. output/<token>.rss
+func renderOutput(c *gin.Context) {
+	token, format, _ := strings.Cut(c.Param("file"), ".")
+	contentType, ok := outputContentTypes[format]
+	if !ok || token == "" {
+		c.JSON(routes.NotFoundError("output"))
+		return
+	}
+	baseURL, ok := contextOutputBaseURL(c)
+	if !ok {
+		return
+	}
+
+	output, err := models.GetOutputForToken(token)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if output == nil || output.User == nil {
+		c.JSON(routes.NotFoundError("output"))
+		return
+	}
+	user := output.User
+
+	count := outputDefaultCount
+	if v, ok := c.GetQuery("n"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > outputMaxCount {
+			c.JSON(routes.InvalidParameterError("n"))
+			return
+		}
+		count = n
+	}
+
+	stream, err := resolveOutputStream(user, output.StreamID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if stream == nil {
+		c.JSON(routes.NotFoundError("stream"))
+		return
+	}
+
+	scopes := append([]func(*gorm.DB) *gorm.DB{models.UserScope(user.ID)}, stream.Scopes...)
+	scopes = append(scopes, models.OrderScope(false), models.CountScope(count))
+
+	ids, _, err := models.ListEntryIDs(scopes...)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	entries, err := models.ListEntriesByIDs(user.ID, ids, false)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	items, err := outputItems(user, entries)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	feed := &outputFeed{
+		HomeURL: stream.Link,
+		Items:   items,
+		SelfURL: fmt.Sprintf("%s/output/%s", baseURL, c.Param("file")),
+		Title:   stream.Title,
+		Updated: time.Now(),
+	}
+	if len(items) > 0 {
+		feed.Updated = items[0].Published
+	}
+
+	var body []byte
+	switch format {
+	case "atom":
+		body, err = xml.Marshal(feed.atom())
+		body = append([]byte(xml.Header), body...)
+	case "json":
+		body, err = json.Marshal(feed.jsonFeed())
+	case "rss":
+		body, err = xml.Marshal(feed.rss())
+		body = append([]byte(xml.Header), body...)
+	}
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, body)
+}
+
+func apiListOutputs(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	outputs, err := models.ListOutputs(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	list := []*APIOutput{}
+	for _, output := range outputs {
+		list = append(list, &APIOutput{
+			ID:        output.ID,
+			CreatedAt: output.CreatedAt,
+			StreamID:  output.StreamID,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"outputs": list,
+	})
+}
+
+func apiCreateOutput(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	var input APIOutputInput
+	if !bindAPIJSON(c, &input) {
+		return
+	}
+	baseURL, ok := contextOutputBaseURL(c)
+	if !ok {
+		return
+	}
+
+	stream, err := resolveOutputStream(user, strings.TrimSpace(input.StreamID))
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if stream == nil {
+		c.JSON(routes.NotFoundError("stream"))
+		return
+	}
+	if len(stream.ID) > 1023 {
+		c.JSON(routes.InvalidParameterError("streamId"))
+		return
+	}
+
+	token, err := utils.SecureRandomHex(outputTokenBytes)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	output, err := models.AddOutput(user.ID, stream.ID, token)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.JSON(http.StatusCreated, &APIOutput{
+		ID:        output.ID,
+		CreatedAt: output.CreatedAt,
+		StreamID:  output.StreamID,
+		URLs:      outputURLs(baseURL, token),
+	})
+}
+
+func apiDeleteOutput(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	count, err := models.DeleteOutput(user.ID, id)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if count == 0 {
+		c.JSON(routes.NotFoundError("output"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,88 @@
+package routes
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testOutputFeed() *outputFeed {
+	published := time.Date(2022, 7, 4, 0, 30, 0, 0, time.UTC)
+	return &outputFeed{
+		HomeURL: "https://example.com/",
+		Items: []*outputItem{
+			{
+				ID:        "tag:google.com,2005:reader/item/0000000000000001",
+				Author:    "Amiya",
+				Content:   "<p>Hi</p>",
+				FeedTitle: "Example",
+				Link:      "https://example.com/1",
+				Published: published,
+				Title:     "A & B",
+			},
+		},
+		SelfURL: "https://reader.example.com/output/token.rss",
+		Title:   "Games",
+		Updated: published,
+	}
+}
+
+func TestOutputFeedRSS(t *testing.T) {
+	body, err := xml.Marshal(testOutputFeed().rss())
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:dc="http://purl.org/dc/elements/1.1/">`)
+	assert.Contains(t, string(body), `<atom:link rel="self" href="https://reader.example.com/output/token.rss" type="application/rss+xml"></atom:link>`)
+	assert.Contains(t, string(body), `<title>A &amp; B</title>`)
+	assert.Contains(t, string(body), `<guid isPermaLink="false">tag:google.com,2005:reader/item/0000000000000001</guid>`)
+	assert.Contains(t, string(body), `<pubDate>Mon, 04 Jul 2022 00:30:00 +0000</pubDate>`)
+	assert.Contains(t, string(body), `<dc:creator>Amiya</dc:creator>`)
+	assert.Contains(t, string(body), `<description>&lt;p&gt;Hi&lt;/p&gt;</description>`)
+}
+
+func TestOutputFeedAtom(t *testing.T) {
+	body, err := xml.Marshal(testOutputFeed().atom())
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), `<feed xmlns="http://www.w3.org/2005/Atom"><id>https://reader.example.com/output/token.rss</id>`)
+	assert.Contains(t, string(body), `<link rel="alternate" href="https://example.com/" type="text/html"></link>`)
+	assert.Contains(t, string(body), `<title type="text">A &amp; B</title>`)
+	assert.Contains(t, string(body), `<updated>2022-07-04T00:30:00Z</updated>`)
+	assert.Contains(t, string(body), `<content type="html">&lt;p&gt;Hi&lt;/p&gt;</content><source><title>Example</title></source>`)
+}
+
+func TestOutputFeedJSON(t *testing.T) {
+	body, err := json.Marshal(testOutputFeed().jsonFeed())
+	assert.Nil(t, err)
+
+	var feed map[string]interface{}
+	assert.Nil(t, json.Unmarshal(body, &feed))
+	assert.Equal(t, "https://jsonfeed.org/version/1.1", feed["version"])
+	assert.Equal(t, "https://reader.example.com/output/token.rss", feed["feed_url"])
+
+	items := feed["items"].([]interface{})
+	assert.Len(t, items, 1)
+	item := items[0].(map[string]interface{})
+	assert.Equal(t, "A & B", item["title"])
+	assert.Equal(t, "<p>Hi</p>", item["content_html"])
+	assert.Equal(t, "2022-07-04T00:30:00Z", item["date_published"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "Amiya"}}, item["authors"])
+
+	body, err = json.Marshal((&outputFeed{}).jsonFeed())
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), `"items":[]`)
+}
+
+func TestOutputBaseURL(t *testing.T) {
+	t.Setenv("APP_URL", "https://reader.example.com/")
+	baseURL, ok := outputBaseURL()
+	assert.True(t, ok)
+	assert.Equal(t, "https://reader.example.com", baseURL)
+
+	for _, v := range []string{"", "reader.example.com", "ftp://reader.example.com", "https://"} {
+		t.Setenv("APP_URL", v)
+		_, ok := outputBaseURL()
+		assert.False(t, ok, v)
+	}
+}
@@ -0,0 +1,409 @@
+package routes
+
+import (
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"reader/internal/app/reader"
+	"reader/internal/app/reader/models"
+	"reader/internal/pkg/routes"
+)
+
+const (
+	feverAPIVersion = 3
+	feverMaxItems   = 50
+)
+
+// FeverFeed Fever feed
+type FeverFeed struct {
+	ID                int64  `json:"id"`
+	FaviconID         int64  `json:"favicon_id"`
+	Title             string `json:"title"`
+	URL               string `json:"url"`
+	SiteURL           string `json:"site_url"`
+	IsSpark           int    `json:"is_spark"`
+	LastUpdatedOnTime int64  `json:"last_updated_on_time"` // timestamp sec
+}
+
+// FeverFeedsGroup Fever feeds of group
+type FeverFeedsGroup struct {
+	GroupID int64  `json:"group_id"`
+	FeedIDs string `json:"feed_ids"` // comma separated
+}
+
+// FeverGroup Fever group
+type FeverGroup struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+// FeverItem Fever item
+type FeverItem struct {
+	ID            int64  `json:"id"`
+	FeedID        int64  `json:"feed_id"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	HTML          string `json:"html"`
+	URL           string `json:"url"`
+	IsSaved       int    `json:"is_saved"`
+	IsRead        int    `json:"is_read"`
+	CreatedOnTime int64  `json:"created_on_time"` // timestamp sec
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func joinIDs(ids []int64) string {
+	s := make([]string, 0, len(ids))
+	for _, id := range ids {
+		s = append(s, strconv.FormatInt(id, 10))
+	}
+	return strings.Join(s, ",")
+}
+
+// feverParam gets parameter from post body, falls back to query
+func feverParam(c *gin.Context, key string) (string, bool) {
+	if v, ok := c.GetPostForm(key); ok {
+		return v, true
+	}
+	return c.GetQuery(key)
+}
+
+// feverUnauthorized writes the Fever response of failed authentication
+func feverUnauthorized(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusOK, gin.H{
+		"api_version": feverAPIVersion,
+		"auth":        0,
+	})
+}
+
+// checkFeverAuth authenticates api_key, which is md5 of "email:password" registered as auth token of user
+func checkFeverAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey, _ := feverParam(c, "api_key")
+		apiKey = strings.ToLower(strings.TrimSpace(apiKey))
+		if apiKey == "" {
+			feverUnauthorized(c)
+			return
+		}
+
+		// api key is derived from password, so failures count for the login limit of IP
+		locked, _, err := loginIPLocked(c)
+		if err != nil {
+			c.AbortWithStatusJSON(routes.InternalServerError())
+			return
+		}
+		if locked {
+			recordAuthEvent(c, &models.AuthEvent{Event: models.AuthEventLoginLocked})
+			feverUnauthorized(c)
+			return
+		}
+
+		authToken, err := authenticateToken(c, apiKey, models.AuthTokenScopeFever)
+		if err != nil {
+			c.AbortWithStatusJSON(routes.InternalServerError())
+			return
+		}
+		if authToken == nil {
+			recordAuthEvent(c, &models.AuthEvent{Event: models.AuthEventLoginFailure})
+			feverUnauthorized(c)
+			return
+		}
+
+		c.Set("authToken", authToken)
+		c.Set("user", authToken.User)
+		c.Next()
+	}
+}
+
+func fever(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	// mark goes first, so that the list it changes is returned along with the requested ones
+	var marked string
+	if mark, ok := feverParam(c, "mark"); ok {
+		var err error
+		if marked, err = feverMark(user, mark, c); err != nil {
+			writeError(c, err)
+			return
+		}
+	}
+	requested := func(key string) bool {
+		_, ok := c.GetQuery(key)
+		return ok || key == marked
+	}
+
+	response := gin.H{
+		"api_version": feverAPIVersion,
+		"auth":        1,
+	}
+
+	lastSuccessAt, err := models.GetLastSuccessAt(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	response["last_refreshed_on_time"] = int64(0)
+	if lastSuccessAt != nil {
+		response["last_refreshed_on_time"] = lastSuccessAt.Unix()
+	}
+
+	if requested("groups") || requested("feeds") {
+		if err := feverGroupsAndFeeds(user, requested("groups"), requested("feeds"), response); err != nil {
+			writeError(c, err)
+			return
+		}
+	}
+
+	if requested("favicons") {
+		// favicons are not stored, clients fall back to their defaults
+		response["favicons"] = []interface{}{}
+	}
+
+	if requested("items") {
+		if err := feverItems(user, c, response); err != nil {
+			writeError(c, err)
+			return
+		}
+	}
+
+	if requested("links") {
+		// hot links of sparks are not supported
+		response["links"] = []interface{}{}
+	}
+
+	for key, state := range map[string]reader.State{
+		"unread_item_ids": reader.StateNotRead,
+		"saved_item_ids":  reader.StateFavorite,
+	} {
+		if !requested(key) {
+			continue
+		}
+
+		ids, _, err := models.ListEntryIDs(models.UserScope(user.ID), models.StateScope(state), models.OrderScope(true))
+		if err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+		response[key] = joinIDs(ids)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// feverGroupsAndFeeds adds groups, feeds and their relations of user to response
+func feverGroupsAndFeeds(user *models.User, groups, feeds bool, response gin.H) error {
+	categories, err := models.ListAllCategoriesWithFeeds(user.ID)
+	if err != nil {
+		return err
+	}
+
+	feverGroups := []*FeverGroup{}
+	feverFeeds := []*FeverFeed{}
+	feedsGroups := []*FeverFeedsGroup{}
+	for _, category := range categories {
+		if len(category.Subscriptions) == 0 && category.UserID != user.ID {
+			continue
+		}
+
+		feverGroups = append(feverGroups, &FeverGroup{
+			ID:    category.ID,
+			Title: html.UnescapeString(category.Name),
+		})
+
+		var feedIDs []int64
+		for _, subscription := range category.Subscriptions {
+			feed := subscription.Feed
+			feedIDs = append(feedIDs, feed.ID)
+
+			feverFeed := &FeverFeed{
+				ID:      feed.ID,
+				Title:   html.UnescapeString(subscription.Name()),
+				URL:     html.UnescapeString(feed.URL),
+				SiteURL: html.UnescapeString(feed.Website),
+			}
+			if feed.LastSuccessAt != nil {
+				feverFeed.LastUpdatedOnTime = feed.LastSuccessAt.Unix()
+			}
+			feverFeeds = append(feverFeeds, feverFeed)
+		}
+		if len(feedIDs) > 0 {
+			feedsGroups = append(feedsGroups, &FeverFeedsGroup{
+				GroupID: category.ID,
+				FeedIDs: joinIDs(feedIDs),
+			})
+		}
+	}
+
+	if groups {
+		response["groups"] = feverGroups
+	}
+	if feeds {
+		response["feeds"] = feverFeeds
+	}
+	response["feeds_groups"] = feedsGroups
+
+	return nil
+}
+
+// feverItems adds at most 50 items of user selected by since_id, max_id or with_ids to response
+func feverItems(user *models.User, c *gin.Context, response gin.H) error {
+	parseID := func(key string) (int64, bool, error) {
+		v, ok := c.GetQuery(key)
+		if !ok || v == "" {
+			return 0, false, nil
+		}
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, false, paramError(key)
+		}
+		return id, true, nil
+	}
+
+	var ids []int64
+	asc := true
+	if v, ok := c.GetQuery("with_ids"); ok {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			id, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return paramError("with_ids")
+			}
+			ids = append(ids, id)
+		}
+		if len(ids) > feverMaxItems {
+			ids = ids[:feverMaxItems]
+		}
+	} else {
+		scopes := []func(*gorm.DB) *gorm.DB{models.UserScope(user.ID)}
+
+		maxID, hasMaxID, err := parseID("max_id")
+		if err != nil {
+			return err
+		}
+		sinceID, _, err := parseID("since_id")
+		if err != nil {
+			return err
+		}
+		if hasMaxID {
+			asc = false
+			scopes = append(scopes, models.OrderScope(false))
+			if maxID > 0 {
+				scopes = append(scopes, models.ContinuationScope(maxID, false))
+			}
+		} else {
+			scopes = append(scopes, models.OrderScope(true), models.ContinuationScope(sinceID, true))
+		}
+		scopes = append(scopes, models.CountScope(feverMaxItems))
+
+		if ids, _, err = models.ListEntryIDs(scopes...); err != nil {
+			return err
+		}
+	}
+
+	items := []*FeverItem{}
+	if len(ids) > 0 {
+		entries, err := models.ListEntriesByIDs(user.ID, ids, asc)
+		if err != nil {
+			return err
+		}
+
+		entryStates, err := models.GetEntryStates(user.ID, ids)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			item := &FeverItem{
+				ID:            entry.ID,
+				FeedID:        entry.FeedID,
+				Title:         html.UnescapeString(entry.Title),
+				Author:        html.UnescapeString(entry.Author),
+				HTML:          entry.Content,
+				URL:           html.UnescapeString(entry.Link),
+				CreatedOnTime: entry.Date.Unix(),
+			}
+			if state, ok := entryStates[entry.ID]; ok {
+				item.IsRead = boolInt(state.Read)
+				item.IsSaved = boolInt(state.Starred)
+			}
+			items = append(items, item)
+		}
+	}
+
+	total, err := models.CountEntries(models.UserScope(user.ID))
+	if err != nil {
+		return err
+	}
+
+	response["items"] = items
+	response["total_items"] = total
+
+	return nil
+}
+
+// feverMark marks item, feed or group of user, returns the query of list which reflects the change
+func feverMark(user *models.User, mark string, c *gin.Context) (string, error) {
+	as, _ := feverParam(c, "as")
+	v, _ := feverParam(c, "id")
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return "", paramError("id")
+	}
+
+	switch mark {
+	case "item":
+		switch as {
+		case "read", "unread":
+			_, err = models.MarkRead(user.ID, []int64{id}, as == "read")
+			return "unread_item_ids", err
+		case "saved", "unsaved":
+			_, err = models.MarkStarred(user.ID, []int64{id}, as == "saved")
+			return "saved_item_ids", err
+		}
+	case "feed", "group":
+		if as != "read" {
+			break
+		}
+
+		var scopes []func(*gorm.DB) *gorm.DB
+		if v, _ := feverParam(c, "before"); v != "" {
+			before, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return "", paramError("before")
+			}
+			scopes = append(scopes, models.StopTimeScope(time.Unix(before, 0)))
+		}
+
+		switch {
+		case mark == "feed":
+			scopes = append(scopes, models.FeedScope(id))
+		case id == 0: // Kindling, all feeds
+			scopes = append(scopes, models.AllScope)
+		case id > 0:
+			scopes = append(scopes, models.CategoryScope(id))
+		default: // Sparks are not supported
+			return "unread_item_ids", nil
+		}
+
+		_, err = models.MarkReadForScopes(user.ID, scopes...)
+		return "unread_item_ids", err
+	}
+
+	return "", paramError("mark")
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoinIDs(t *testing.T) {
+	assert.Equal(t, "", joinIDs(nil))
+	assert.Equal(t, "1,20,300", joinIDs([]int64{1, 20, 300}))
+}
+
+func TestFeverAuthMissingKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("api/fever.php", checkFeverAuth(), fever)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/fever.php?api", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"api_version":3,"auth":0}`, w.Body.String())
+}
@@ -0,0 +1,473 @@
+package routes
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"reader/internal/app/reader"
+	"reader/internal/app/reader/models"
+	"reader/internal/pkg/routes"
+	"reader/internal/pkg/utils"
+)
+
+const (
+	newsAPIVersion = "15.3.2" // Nextcloud News version of API v1-3
+
+	newsTypeFeed    = 0
+	newsTypeFolder  = 1
+	newsTypeStarred = 2
+	newsTypeAll     = 3
+)
+
+// NewsFeed Nextcloud News feed
+type NewsFeed struct {
+	ID               int64  `json:"id"`
+	URL              string `json:"url"`
+	Title            string `json:"title"`
+	FaviconLink      string `json:"faviconLink"`
+	Added            int64  `json:"added"` // timestamp sec, unknown as 0
+	FolderID         int64  `json:"folderId"`
+	UnreadCount      int64  `json:"unreadCount"`
+	Ordering         int    `json:"ordering"`
+	Link             string `json:"link"`
+	Pinned           bool   `json:"pinned"`
+	UpdateErrorCount int32  `json:"updateErrorCount"`
+	LastUpdateError  string `json:"lastUpdateError"`
+}
+
+// NewsFolder Nextcloud News folder
+type NewsFolder struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// NewsItem Nextcloud News item
+type NewsItem struct {
+	ID            int64   `json:"id"`
+	GUID          string  `json:"guid"`
+	GUIDHash      string  `json:"guidHash"`
+	URL           string  `json:"url"`
+	Title         string  `json:"title"`
+	Author        string  `json:"author"`
+	PubDate       int64   `json:"pubDate"` // timestamp sec
+	Body          string  `json:"body"`
+	EnclosureMime *string `json:"enclosureMime"`
+	EnclosureLink *string `json:"enclosureLink"`
+	FeedID        int64   `json:"feedId"`
+	Unread        bool    `json:"unread"`
+	Starred       bool    `json:"starred"`
+	LastModified  int64   `json:"lastModified"` // timestamp sec
+	RTL           bool    `json:"rtl"`
+	Fingerprint   string  `json:"fingerprint"`
+	ContentHash   string  `json:"contentHash"`
+}
+
+// NewsItemIDs Nextcloud News multiple items body, items is the key of API v1-2
+type NewsItemIDs struct {
+	ItemIDs []int64 `json:"itemIds"`
+	Items   []int64 `json:"items"`
+}
+
+// checkBasicAuth authenticates basic auth of request, the password is either an auth token of user or the account
+// password, both are checked only under the failed login limit and a password matching neither counts as a failure
+func checkBasicAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		unauthorized := func() {
+			c.Header("WWW-Authenticate", `Basic realm="OnionReader"`)
+			c.AbortWithStatusJSON(routes.InvalidCredentialsError(""))
+		}
+
+		email, password, ok := c.Request.BasicAuth()
+		if !ok {
+			unauthorized()
+			return
+		}
+
+		locked, retryAfter, err := loginLocked(c, email)
+		if err != nil {
+			c.AbortWithStatusJSON(routes.InternalServerError())
+			return
+		}
+		if locked {
+			recordAuthEvent(c, &models.AuthEvent{
+				Event: models.AuthEventLoginLocked,
+				Email: email,
+			})
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+			c.AbortWithStatusJSON(routes.TooManyRequestsError())
+			return
+		}
+
+		authToken, err := authenticateToken(c, password, models.AuthTokenScopeFull)
+		if err != nil {
+			c.AbortWithStatusJSON(routes.InternalServerError())
+			return
+		}
+		if authToken != nil && authToken.User.Email == email {
+			c.Set("authToken", authToken)
+			c.Set("user", authToken.User)
+			c.Next()
+			return
+		}
+
+		user, err := models.GetUser(email)
+		if err != nil {
+			c.AbortWithStatusJSON(routes.InternalServerError())
+			return
+		}
+		if !verifyLogin(user, password) {
+			event := &models.AuthEvent{
+				Event: models.AuthEventLoginFailure,
+				Email: email,
+			}
+			if user != nil {
+				event.UserID = &user.ID
+			}
+			recordAuthEvent(c, event)
+			unauthorized()
+			return
+		}
+
+		c.Set("user", user)
+		c.Next()
+	}
+}
+
+// newsItemScopes generates query scopes selecting entries of user for item type and ID
+func newsItemScopes(user *models.User, c *gin.Context) ([]func(*gorm.DB) *gorm.DB, error) {
+	itemType, err := strconv.Atoi(c.DefaultQuery("type", strconv.Itoa(newsTypeAll)))
+	if err != nil {
+		return nil, paramError("type")
+	}
+	id, err := strconv.ParseInt(c.DefaultQuery("id", "0"), 10, 64)
+	if err != nil {
+		return nil, paramError("id")
+	}
+
+	scopes := []func(*gorm.DB) *gorm.DB{models.UserScope(user.ID)}
+	switch itemType {
+	case newsTypeFeed:
+		scopes = append(scopes, models.FeedScope(id))
+	case newsTypeFolder:
+		scopes = append(scopes, models.CategoryScope(id))
+	case newsTypeStarred:
+		scopes = append(scopes, models.StarredScope)
+	case newsTypeAll:
+		scopes = append(scopes, models.AllScope)
+	default:
+		return nil, paramError("type")
+	}
+
+	return scopes, nil
+}
+
+// newsItems renders entries of IDs as Nextcloud News items of user
+func newsItems(user *models.User, ids []int64, asc bool) ([]*NewsItem, error) {
+	items := []*NewsItem{}
+	if len(ids) == 0 {
+		return items, nil
+	}
+
+	entries, err := models.ListEntriesByIDs(user.ID, ids, asc)
+	if err != nil {
+		return nil, err
+	}
+
+	entryStates, err := models.GetEntryStates(user.ID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		item := &NewsItem{
+			ID:           entry.ID,
+			GUID:         entry.GUID,
+			GUIDHash:     utils.Md5(entry.GUID),
+			URL:          html.UnescapeString(entry.Link),
+			Title:        html.UnescapeString(entry.Title),
+			Author:       html.UnescapeString(entry.Author),
+			PubDate:      entry.Date.Unix(),
+			Body:         entry.Content,
+			FeedID:       entry.FeedID,
+			Unread:       true,
+			LastModified: entry.CreatedAt.Unix(),
+			Fingerprint:  utils.Md5(fmt.Sprintf("%s%s%s", entry.Link, entry.Title, entry.Content)),
+			ContentHash:  utils.Md5(entry.Content),
+		}
+		if state, ok := entryStates[entry.ID]; ok {
+			item.Unread = !state.Read
+			item.Starred = state.Starred
+			if state.UpdatedAt != nil && state.UpdatedAt.After(entry.CreatedAt) {
+				item.LastModified = state.UpdatedAt.Unix()
+			}
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func newsVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"version": newsAPIVersion,
+	})
+}
+
+func listNewsFolders(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	categories, err := models.ListCategories(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	folders := []*NewsFolder{}
+	for _, category := range categories {
+		folders = append(folders, &NewsFolder{
+			ID:   category.ID,
+			Name: html.UnescapeString(category.Name),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"folders": folders,
+	})
+}
+
+func listNewsFeeds(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	categories, err := models.ListAllCategoriesWithFeeds(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	unreadCounts, err := models.CountUnreadByFeed(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	feedUnreadCounts := make(map[int64]int64)
+	for _, unreadCount := range unreadCounts {
+		feedUnreadCounts[unreadCount.ID] = unreadCount.Count
+	}
+
+	feeds := []*NewsFeed{}
+	for _, category := range categories {
+		for _, subscription := range category.Subscriptions {
+			feed := subscription.Feed
+
+			newsFeed := &NewsFeed{
+				ID:               feed.ID,
+				URL:              html.UnescapeString(feed.URL),
+				Title:            html.UnescapeString(subscription.Name()),
+				FolderID:         category.ID,
+				UnreadCount:      feedUnreadCounts[feed.ID],
+				Link:             html.UnescapeString(feed.Website),
+				UpdateErrorCount: feed.FailureCount,
+				LastUpdateError:  feed.LastError,
+			}
+			feeds = append(feeds, newsFeed)
+		}
+	}
+
+	starredCount, err := models.CountEntries(models.UserScope(user.ID), models.StarredScope)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	response := gin.H{
+		"feeds":        feeds,
+		"starredCount": starredCount,
+	}
+
+	newestIDs, _, err := models.ListEntryIDs(models.UserScope(user.ID), models.OrderScope(false), models.CountScope(1))
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if len(newestIDs) > 0 {
+		response["newestItemId"] = newestIDs[0]
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func listNewsItems(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	scopes, err := newsItemScopes(user, c)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	getRead, err := strconv.ParseBool(c.DefaultQuery("getRead", "true"))
+	if err != nil {
+		c.JSON(routes.InvalidParameterError("getRead"))
+		return
+	}
+	if !getRead {
+		scopes = append(scopes, models.StateScope(reader.StateNotRead))
+	}
+
+	asc, err := strconv.ParseBool(c.DefaultQuery("oldestFirst", "false"))
+	if err != nil {
+		c.JSON(routes.InvalidParameterError("oldestFirst"))
+		return
+	}
+	scopes = append(scopes, models.OrderScope(asc))
+
+	// offset is the ID of the last item of previous batch
+	offset, err := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	if err != nil {
+		c.JSON(routes.InvalidParameterError("offset"))
+		return
+	}
+	if offset > 0 {
+		scopes = append(scopes, models.ContinuationScope(offset, asc))
+	}
+
+	batchSize, err := strconv.Atoi(c.DefaultQuery("batchSize", "-1"))
+	if err != nil {
+		c.JSON(routes.InvalidParameterError("batchSize"))
+		return
+	}
+	if batchSize > 0 {
+		scopes = append(scopes, models.CountScope(batchSize))
+	}
+
+	ids, _, err := models.ListEntryIDs(scopes...)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	items, err := newsItems(user, ids, asc)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": items,
+	})
+}
+
+func listNewsUpdatedItems(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	scopes, err := newsItemScopes(user, c)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	lastModified, err := strconv.ParseInt(c.Query("lastModified"), 10, 64)
+	if err != nil {
+		c.JSON(routes.InvalidParameterError("lastModified"))
+		return
+	}
+	// newer clients send microseconds
+	if lastModified > 1e12 {
+		lastModified /= 1e6
+	}
+	scopes = append(scopes, models.ModifiedSinceScope(time.Unix(lastModified, 0)), models.OrderScope(true))
+
+	ids, _, err := models.ListEntryIDs(scopes...)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	items, err := newsItems(user, ids, true)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": items,
+	})
+}
+
+// markNewsItem marks the item of path for read or starred state
+func markNewsItem(read, value bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := contextUser(c)
+		if !ok {
+			return
+		}
+
+		id, err := strconv.ParseInt(c.Param("itemId"), 10, 64)
+		if err != nil {
+			c.JSON(routes.InvalidParameterError("itemId"))
+			return
+		}
+
+		if err := markNewsItemIDs(user, []int64{id}, read, value); err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+
+		c.Status(http.StatusOK)
+	}
+}
+
+// markNewsItems marks the items of body for read or starred state
+func markNewsItems(read, value bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := contextUser(c)
+		if !ok {
+			return
+		}
+
+		var body NewsItemIDs
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(routes.InvalidParameterError("itemIds"))
+			return
+		}
+
+		ids := append(body.ItemIDs, body.Items...)
+		if len(ids) > 0 {
+			if err := markNewsItemIDs(user, ids, read, value); err != nil {
+				c.JSON(routes.InternalServerError())
+				return
+			}
+		}
+
+		c.Status(http.StatusOK)
+	}
+}
+
+func markNewsItemIDs(user *models.User, ids []int64, read, value bool) error {
+	var err error
+	if read {
+		_, err = models.MarkRead(user.ID, ids, value)
+	} else {
+		_, err = models.MarkStarred(user.ID, ids, value)
+	}
+
+	return err
+}
@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"reader/internal/app/reader/models"
+)
+
+func TestNewsBasicAuthMissing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("folders", checkBasicAuth(), listNewsFolders)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/folders", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Basic realm="OnionReader"`, w.Header().Get("WWW-Authenticate"))
+}
+
+func TestNewsItemScopes(t *testing.T) {
+	user := &models.User{ID: 1}
+	for query, expected := range map[string]error{
+		"":              nil,
+		"type=0&id=2":   nil,
+		"type=2":        nil,
+		"type=4":        paramError("type"),
+		"type=1&id=abc": paramError("id"),
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request, _ = http.NewRequest("GET", "/items?"+query, nil)
+
+		scopes, err := newsItemScopes(user, c)
+		assert.Equal(t, expected, err, query)
+		if err == nil {
+			assert.Len(t, scopes, 2, query)
+		}
+	}
+}
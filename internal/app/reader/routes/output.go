@@ -0,0 +1,266 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"reader/internal/app/reader"
+	"reader/internal/pkg/routes"
+)
+
+const (
+	googleReaderItemPrefix = "tag:google.com,2005:reader/"
+	googleReaderScheme     = "http://www.google.com/reader/"
+)
+
+// atomRenderer is implemented by responses which can be rendered as Google-Reader-style Atom feed
+type atomRenderer interface {
+	atom() *atomFeed
+}
+
+// streamResponse stream contents response
+type streamResponse struct {
+	*reader.StreamContents
+}
+
+type atomCategory struct {
+	Term   string `xml:"term,attr"`
+	Scheme string `xml:"scheme,attr"`
+	Label  string `xml:"label,attr"`
+}
+
+type atomEntry struct {
+	CrawlTimestampMSec string          `xml:"gr:crawl-timestamp-msec,attr"`
+	ID                 string          `xml:"id"`
+	Categories         []*atomCategory `xml:"category"`
+	Title              atomText        `xml:"title"`
+	Published          string          `xml:"published"`
+	Updated            string          `xml:"updated"`
+	Links              []*atomLink     `xml:"link"`
+	Summary            atomText        `xml:"summary"`
+	Author             *atomPerson     `xml:"author,omitempty"`
+	Source             atomSource      `xml:"source"`
+}
+
+type atomFeed struct {
+	XMLName      xml.Name     `xml:"feed"`
+	XMLNS        string       `xml:"xmlns,attr"`
+	XMLNSGR      string       `xml:"xmlns:gr,attr"`
+	Generator    string       `xml:"generator"`
+	ID           string       `xml:"id"`
+	Title        string       `xml:"title"`
+	Continuation string       `xml:"gr:continuation,omitempty"`
+	Updated      string       `xml:"updated"`
+	Entries      []*atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomSource struct {
+	StreamID string   `xml:"gr:stream-id,attr"`
+	ID       string   `xml:"id"`
+	Title    atomText `xml:"title"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+func atomTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func (s *streamResponse) atom() *atomFeed {
+	feed := &atomFeed{
+		XMLNS:        "http://www.w3.org/2005/Atom",
+		XMLNSGR:      "http://www.google.com/schemas/reader/atom/",
+		Generator:    "OnionReader",
+		ID:           googleReaderItemPrefix + s.ID,
+		Title:        s.Title,
+		Continuation: s.Continuation,
+		Updated:      atomTime(time.Unix(s.Updated, 0)),
+	}
+
+	for _, item := range s.Items {
+		usec, _ := strconv.ParseInt(item.TimestampUSec, 10, 64)
+		date := atomTime(time.UnixMicro(usec))
+
+		entry := &atomEntry{
+			CrawlTimestampMSec: item.CrawlTimeMSec,
+			ID:                 item.ID,
+			Title:              atomText{Type: "html", Text: item.Title},
+			Published:          atomTime(time.Unix(item.Published, 0)),
+			Updated:            date,
+			Summary:            atomText{Type: "html", Text: item.Summary.Content},
+			Source: atomSource{
+				StreamID: item.Origin.StreamID,
+				ID:       googleReaderItemPrefix + item.Origin.StreamID,
+				Title:    atomText{Type: "html", Text: item.Origin.Title},
+			},
+		}
+
+		for _, category := range item.Categories {
+			entry.Categories = append(entry.Categories, &atomCategory{
+				Term:   category,
+				Scheme: googleReaderScheme,
+				Label:  category[strings.LastIndex(category, "/")+1:],
+			})
+		}
+		for _, alternate := range item.Alternate {
+			entry.Links = append(entry.Links, &atomLink{
+				Rel:  "alternate",
+				Href: alternate.Href,
+				Type: "text/html",
+			})
+		}
+		if item.Author != "" {
+			entry.Author = &atomPerson{Name: item.Author}
+		}
+
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return feed
+}
+
+// render renders response in the format requested by the output parameter: json (default), atom or xml
+func render(c *gin.Context, v interface{}) {
+	switch c.Request.URL.Query().Get("output") {
+	case "", "json":
+		c.JSON(http.StatusOK, v)
+	case "atom":
+		a, ok := v.(atomRenderer)
+		if !ok {
+			c.JSON(routes.InvalidParameterError("output"))
+			return
+		}
+
+		body, err := xml.Marshal(a.atom())
+		if err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+		c.Data(http.StatusOK, "application/atom+xml; charset=utf-8", append([]byte(xml.Header), body...))
+	case "xml":
+		body, err := xmlObject(v)
+		if err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+		c.Data(http.StatusOK, "text/xml; charset=utf-8", append([]byte(xml.Header), body...))
+	default:
+		c.JSON(routes.InvalidParameterError("output"))
+	}
+}
+
+// xmlObject encodes the JSON representation of v in Google Reader's XML object format
+func xmlObject(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := writeXMLValue(&buf, "", value); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeXMLElement(buf *bytes.Buffer, tag, name, text string) error {
+	buf.WriteString("<" + tag)
+	if name != "" {
+		buf.WriteString(` name="`)
+		if err := xml.EscapeText(buf, []byte(name)); err != nil {
+			return err
+		}
+		buf.WriteString(`"`)
+	}
+	buf.WriteString(">")
+	if err := xml.EscapeText(buf, []byte(text)); err != nil {
+		return err
+	}
+	buf.WriteString("</" + tag + ">")
+
+	return nil
+}
+
+func writeXMLValue(buf *bytes.Buffer, name string, value interface{}) error {
+	open := func(tag string) {
+		if name == "" {
+			buf.WriteString("<" + tag + ">")
+		} else {
+			buf.WriteString(fmt.Sprintf(`<%s name="%s">`, tag, escapeXMLAttr(name)))
+		}
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		open("object")
+		for _, key := range keys {
+			if err := writeXMLValue(buf, key, v[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("</object>")
+	case []interface{}:
+		open("list")
+		for _, item := range v {
+			if err := writeXMLValue(buf, "", item); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("</list>")
+	case string:
+		return writeXMLElement(buf, "string", name, v)
+	case json.Number:
+		return writeXMLElement(buf, "number", name, v.String())
+	case bool:
+		return writeXMLElement(buf, "boolean", name, strconv.FormatBool(v))
+	default:
+		return fmt.Errorf("unsupported value type %T", value)
+	}
+
+	return nil
+}
+
+func escapeXMLAttr(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
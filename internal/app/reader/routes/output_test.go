@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"reader/internal/app/reader"
+)
+
+func TestXMLObject(t *testing.T) {
+	body, err := xmlObject(map[string]interface{}{
+		"subscriptions": []*Feed{
+			{
+				ID:         "feed/1",
+				Categories: []*Category{{ID: "user/-/label/Games", Label: "Games"}},
+				Title:      "A & B",
+			},
+		},
+		"count": 2,
+		"ok":    true,
+	})
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), `<object><number name="count">2</number><boolean name="ok">true</boolean><list name="subscriptions"><object>`)
+	assert.Contains(t, string(body), `<string name="title">A &amp; B</string>`)
+	assert.Contains(t, string(body), `<list name="categories"><object><string name="id">user/-/label/Games</string>`)
+}
+
+func TestStreamResponseAtom(t *testing.T) {
+	res := &streamResponse{&reader.StreamContents{
+		ID:      "feed/1",
+		Title:   "Example",
+		Updated: 1656894600,
+		Items: []*reader.StreamContentItem{
+			{
+				ID:            "tag:google.com,2005:reader/item/0000000000000001",
+				Categories:    []string{"user/-/state/com.google/reading-list", "user/-/label/Games"},
+				CrawlTimeMSec: "1656894600000",
+				Origin:        reader.StreamContentItemOrigin{StreamID: "feed/1", Title: "Example"},
+				Published:     1656894600,
+				Summary:       reader.StreamContentItemSummary{Content: "<p>Hi</p>"},
+				TimestampUSec: "1656894600000000",
+				Title:         "Entry",
+			},
+		},
+	}}
+
+	body, err := xml.Marshal(res.atom())
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:gr="http://www.google.com/schemas/reader/atom/">`)
+	assert.Contains(t, string(body), `<id>tag:google.com,2005:reader/feed/1</id>`)
+	assert.Contains(t, string(body), `<entry gr:crawl-timestamp-msec="1656894600000">`)
+	assert.Contains(t, string(body), `<category term="user/-/label/Games" scheme="http://www.google.com/reader/" label="Games"></category>`)
+	assert.Contains(t, string(body), `<summary type="html">&lt;p&gt;Hi&lt;/p&gt;</summary>`)
+	assert.Contains(t, string(body), `<updated>2022-07-04T00:30:00Z</updated>`)
+}
@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"html"
@@ -15,11 +16,19 @@ import (
 	"gorm.io/gorm"
 
 	"reader/internal/app/reader"
+	"reader/internal/app/reader/feeds/feeds"
+	"reader/internal/app/reader/feeds/generic"
+	"reader/internal/app/reader/filter"
 	"reader/internal/app/reader/models"
+	"reader/internal/app/reader/opml"
 	"reader/internal/pkg/routes"
 	"reader/internal/pkg/utils"
 )
 
+const (
+	importMaxBodySize = 10 << 20 // bytes of OPML document
+)
+
 // Category category
 type Category struct {
 	ID    string `json:"id"`
@@ -36,6 +45,29 @@ type Feed struct {
 	URL        string      `json:"url"`
 }
 
+// QuickAddResult quick add subscription result
+type QuickAddResult struct {
+	Error      string `json:"error,omitempty"`
+	NumResults int    `json:"numResults"`
+	Query      string `json:"query"`
+	StreamID   string `json:"streamId,omitempty"`
+	StreamName string `json:"streamName,omitempty"`
+}
+
+// Tag tag
+type Tag struct {
+	ID          string `json:"id"`
+	Type        string `json:"type,omitempty"`
+	UnreadCount *int64 `json:"unread_count,omitempty"`
+}
+
+// UnreadCount unread count
+type UnreadCount struct {
+	ID                      string `json:"id"`
+	Count                   int64  `json:"count"`
+	NewestItemTimestampUSec string `json:"newestItemTimestampUsec"`
+}
+
 func parseEntryID(id string) (int64, error) {
 	if utils.AllDigits(id) && !strings.HasPrefix(id, "0") {
 		_id, err := strconv.ParseInt(id, 10, 64)
@@ -54,11 +86,33 @@ func parseEntryID(id string) (int64, error) {
 	return _id, nil
 }
 
+// contextUser gets the authenticated user, the error response is written on failure
+func contextUser(c *gin.Context) (*models.User, bool) {
+	userData, ok := c.Get("user")
+	if !ok {
+		c.JSON(routes.InternalServerError())
+		return nil, false
+	}
+
+	return userData.(*models.User), true
+}
+
+func firstPostValue(bodyPosts map[string][]string, key string) string {
+	if v, ok := bodyPosts[key]; ok && len(v) > 0 {
+		return v[0]
+	}
+
+	return ""
+}
+
 func parsePostBody(body string) (map[string][]string, error) {
 	params := make(map[string][]string)
 
 	inputs := strings.Split(body, "&")
 	for _, input := range inputs {
+		if input == "" {
+			continue
+		}
 		i := strings.Split(input, "=")
 		if len(i) != 2 {
 			return nil, errors.New("invalid body format")
@@ -76,6 +130,78 @@ func parsePostBody(body string) (map[string][]string, error) {
 	return params, nil
 }
 
+// parseCheckedPostBody parses post body and checks its token, the error response is written on failure
+func parseCheckedPostBody(c *gin.Context) (map[string][]string, *models.User, bool) {
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return nil, nil, false
+	}
+
+	bodyPosts, err := parsePostBody(string(body))
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return nil, nil, false
+	}
+
+	v, ok := bodyPosts["T"]
+	if !ok || len(v) != 1 {
+		c.JSON(routes.InvalidParameterError("T"))
+		return nil, nil, false
+	}
+
+	user, ok := checkActionToken(c, v[0])
+	if !ok {
+		return nil, nil, false
+	}
+
+	return bodyPosts, user, true
+}
+
+// checkActionToken checks the action token T of request, the error response is written on failure
+func checkActionToken(c *gin.Context, token string) (*models.User, bool) {
+	user, ok := contextUser(c)
+	if !ok {
+		return nil, false
+	}
+	authToken, ok := contextAuthToken(c)
+	if !ok {
+		return nil, false
+	}
+	if !checkToken(authToken, utils.Trim(token)) {
+		c.JSON(routes.InvalidCredentialsError("token"))
+		return nil, false
+	}
+
+	return user, true
+}
+
+// parseFeedStreamID parses feed ID from feed stream ID without prefix, which is either ID or URL, -1 for not found
+func parseFeedStreamID(streamID string) (int64, error) {
+	if streamID == "" {
+		return -1, nil
+	}
+	if id, err := strconv.ParseInt(streamID, 10, 64); err == nil {
+		return id, nil
+	}
+
+	return models.GetFeedIDForURL(streamID)
+}
+
+// parseLabel parses label name from label stream ID, empty for other streams
+func parseLabel(user *models.User, streamID string) string {
+	if strings.HasPrefix(streamID, "user/-/label/") {
+		return streamID[13:]
+	}
+
+	prefix := fmt.Sprintf("user/%d/label/", user.ID)
+	if strings.HasPrefix(streamID, prefix) {
+		return streamID[len(prefix):]
+	}
+
+	return ""
+}
+
 func parseStreamParams(c *gin.Context) *reader.StreamParams {
 	var params reader.StreamParams
 	params.Exclude = c.Request.URL.Query().Get("xt")
@@ -108,192 +234,78 @@ func parseStreamParams(c *gin.Context) *reader.StreamParams {
 			params.Continuation = v
 		}
 	}
+	params.Query = strings.TrimSpace(c.Request.URL.Query().Get("q"))
 
 	return &params
 }
 
-func editTag(c *gin.Context) {
-	body, err := ioutil.ReadAll(c.Request.Body)
-	if err != nil {
-		c.JSON(routes.InternalServerError())
-		return
-	}
+// savedSearchLabel saved search compiled as virtual label
+type savedSearchLabel struct {
+	compiled *filter.Compiled
+	name     string // unescaped
+}
 
-	bodyPosts, err := parsePostBody(string(body))
+// compileSavedSearch compiles query of saved search for user, nil if it no longer resolves
+func compileSavedSearch(user *models.User, search *models.SavedSearch) (*filter.Compiled, error) {
+	f, err := filter.Parse(search.Query)
 	if err != nil {
-		c.JSON(routes.InternalServerError())
-		return
-	}
-
-	v, ok := bodyPosts["T"]
-	if !ok || len(v) != 1 {
-		c.JSON(routes.InvalidParameterError("T"))
-		return
-	}
-
-	token := utils.Trim(v[0])
-
-	userData, ok := c.Get("user")
-	if !ok {
-		c.JSON(routes.InternalServerError())
-		return
-	}
-
-	if !checkToken(userData.(*models.User), token) {
-		c.JSON(routes.InvalidCredentialsError("token"))
-		return
+		return nil, nil
 	}
 
-	addTag := ""
-	if v, ok = bodyPosts["a"]; ok {
-		addTag = v[0]
+	compiled, err := f.Compile(user.ID, time.Now())
+	if errors.Is(err, filter.ErrUnresolved) {
+		return nil, nil
 	}
 
-	removeTag := ""
-	if v, ok = bodyPosts["r"]; ok {
-		removeTag = v[0]
-	}
+	return compiled, err
+}
 
-	ids, ok := bodyPosts["i"]
-	if !ok {
-		c.JSON(routes.InvalidParameterError("i"))
-		return
+// savedSearchLabels lists saved searches of user which resolve as virtual labels
+func savedSearchLabels(user *models.User) ([]*savedSearchLabel, error) {
+	searches, err := models.ListSavedSearches(user.ID)
+	if err != nil {
+		return nil, err
 	}
 
-	var entryIDs []int64
-	for _, id := range ids {
-		_id, err := parseEntryID(id)
+	var labels []*savedSearchLabel
+	for _, search := range searches {
+		compiled, err := compileSavedSearch(user, search)
 		if err != nil {
-			c.JSON(routes.InvalidParameterError("i"))
-			return
-		}
-
-		entryIDs = append(entryIDs, _id)
-	}
-
-	switch addTag {
-	case "user/-/state/com.google/read":
-		if _, err := models.MarkRead(entryIDs, true); err != nil {
-			c.JSON(routes.InternalServerError())
-			return
-		}
-	case "user/-/state/com.google/starred":
-		if _, err := models.MarkFavorite(entryIDs, true); err != nil {
-			c.JSON(routes.InternalServerError())
-			return
-		}
-	default:
-		tagName := ""
-		if strings.HasPrefix(addTag, "user/-/label/") {
-			tagName = addTag[13:]
-		} else {
-			prefix := fmt.Sprintf("user/%d/label/", userData.(*models.User).ID)
-			if strings.HasPrefix(addTag, prefix) {
-				tagName = addTag[len(prefix):]
-			}
+			return nil, err
 		}
-		if tagName != "" {
-			tagName = html.EscapeString(tagName)
-			tagID, err := models.GetTagIDForName(tagName)
-			if err != nil {
-				c.JSON(routes.InternalServerError())
-				return
-			}
-			if tagID == -1 {
-				_id, err := models.AddTag(tagName)
-				if err != nil {
-					c.JSON(routes.InternalServerError())
-					return
-				}
-				tagID = _id
-			}
-			if tagID != -1 {
-				models.AddTagForEntries(tagID, entryIDs)
-			}
+		if compiled == nil {
+			continue
 		}
-	}
 
-	switch removeTag {
-	case "user/-/state/com.google/read":
-		if _, err := models.MarkRead(entryIDs, false); err != nil {
-			c.JSON(routes.InternalServerError())
-			return
-		}
-	case "user/-/state/com.google/starred":
-		if _, err := models.MarkFavorite(entryIDs, false); err != nil {
-			c.JSON(routes.InternalServerError())
-			return
-		}
-	default:
-		if strings.HasPrefix(removeTag, "user/-/label/") {
-			tagName := html.EscapeString(removeTag[13:])
-			tagID, err := models.GetTagIDForName(tagName)
-			if err != nil {
-				c.JSON(routes.InternalServerError())
-				return
-			}
-			if tagID != -1 {
-				models.RemoveTagForEntries(tagID, entryIDs)
-			}
-		}
+		labels = append(labels, &savedSearchLabel{
+			compiled: compiled,
+			name:     html.UnescapeString(search.Name),
+		})
 	}
 
-	c.String(http.StatusOK, "OK")
+	return labels, nil
 }
 
-func listStreamItemContents(c *gin.Context) {
-	params := parseStreamParams(c)
-
-	body, err := ioutil.ReadAll(c.Request.Body)
-	if err != nil {
-		c.JSON(routes.InternalServerError())
-		return
-	}
-
-	bodyPosts, err := parsePostBody(string(body))
+// streamContentItems renders entries as stream content items of user
+func streamContentItems(user *models.User, entries []*models.Entry) ([]*reader.StreamContentItem, error) {
+	feedCategoryNames, err := models.GetFeedAndCategoryNames(user.ID)
 	if err != nil {
-		c.JSON(routes.InternalServerError())
-		return
-	}
-
-	ids, ok := bodyPosts["i"]
-	if !ok {
-		c.JSON(routes.InvalidParameterError("i"))
-		return
+		return nil, err
 	}
 
 	var entryIDs []int64
-	for _, id := range ids {
-		_id, err := parseEntryID(id)
-		if err != nil {
-			c.JSON(routes.InvalidParameterError("i"))
-			return
-		}
-
-		entryIDs = append(entryIDs, _id)
-	}
-
-	entries, err := models.ListEntriesByIDs(entryIDs, params.Order)
-	if err != nil {
-		c.JSON(routes.InternalServerError())
-		return
+	for _, entry := range entries {
+		entryIDs = append(entryIDs, entry.ID)
 	}
 
-	feedCategoryNames, err := models.GetFeedAndCategoryNames()
+	entryStates, err := models.GetEntryStates(user.ID, entryIDs)
 	if err != nil {
-		c.JSON(routes.InternalServerError())
-		return
-	}
-
-	entryIDs = []int64{}
-	for _, entry := range entries {
-		entryIDs = append(entryIDs, entry.ID)
+		return nil, err
 	}
 
-	entryTagNames, err := models.GetTagNamesForEntryIDs(entryIDs)
+	entryTagNames, err := models.GetTagNamesForEntryIDs(user.ID, entryIDs)
 	if err != nil {
-		c.JSON(routes.InternalServerError())
-		return
+		return nil, err
 	}
 
 	var items []*reader.StreamContentItem
@@ -337,11 +349,13 @@ func listStreamItemContents(c *gin.Context) {
 			Title:         utils.EscapeToUnicodeAlternative(entry.Title, false),
 		}
 
-		if entry.Read {
-			item.Categories = append(item.Categories, "user/-/state/com.google/read")
-		}
-		if entry.Favorite {
-			item.Categories = append(item.Categories, "user/-/state/com.google/starred")
+		if state, ok := entryStates[entry.ID]; ok {
+			if state.Read {
+				item.Categories = append(item.Categories, "user/-/state/com.google/read")
+			}
+			if state.Starred {
+				item.Categories = append(item.Categories, "user/-/state/com.google/starred")
+			}
 		}
 		if tagNames, ok := entryTagNames[entry.ID]; ok {
 			for _, tagName := range tagNames {
@@ -353,73 +367,20 @@ func listStreamItemContents(c *gin.Context) {
 		items = append(items, &item)
 	}
 
-	output := c.Request.URL.Query().Get("output")
-	switch output {
-	case "":
-		fallthrough
-	case "json":
-		c.JSON(http.StatusOK, gin.H{
-			"id":      "user/-/state/com.google/reading-list",
-			"updated": time.Now().Unix(),
-			"items":   items,
-		})
-		return
-	default:
-		c.JSON(routes.InvalidParameterError("output"))
-	}
+	return items, nil
 }
 
-func listStreamItemIds(c *gin.Context) {
-	params := parseStreamParams(c)
-
-	streamID := c.Request.URL.Query().Get("s")
-	if streamID == "" {
-		c.JSON(routes.InvalidParameterError("s"))
-		return
+// streamItemScopes generates query scopes selecting entries of stream for user filtered by parameters
+func streamItemScopes(user *models.User, streamID string, params *reader.StreamParams) ([]func(*gorm.DB) *gorm.DB, error) {
+	stream, err := streamScopes(user, streamID)
+	if err != nil {
+		return nil, err
 	}
-
-	var scopes []func(*gorm.DB) *gorm.DB
-
-	if streamID == "user/-/state/com.google/reading-list" {
-		scopes = append(scopes, models.AllScope)
-	} else if streamID == "user/-/state/com.google/starred" {
-		scopes = append(scopes, models.StarredScope)
-	} else if strings.HasPrefix(streamID, "feed/") {
-		streamID = streamID[5:]
-
-		var feedID int64
-		if streamID == "" {
-			feedID = -1
-		} else if i, err := strconv.ParseInt(streamID, 10, 64); err == nil {
-			feedID = i
-		} else if feedID, err = models.GetFeedIDForURL(streamID); err != nil {
-			c.JSON(routes.InternalServerError())
-			return
-		}
-		scopes = append(scopes, models.FeedScope(feedID))
-	} else if strings.HasPrefix(streamID, "user/-/label/") {
-		streamID = streamID[13:]
-
-		categoryID, err := models.GetCategoryIDForName(streamID)
-		if err != nil {
-			c.JSON(routes.InternalServerError())
-			return
-		}
-		if categoryID != -1 {
-			scopes = append(scopes, models.CategoryScope(categoryID))
-		} else {
-			tagID, err := models.GetTagIDForName(streamID)
-			if err != nil {
-				c.JSON(routes.InternalServerError())
-				return
-			}
-			if tagID != -1 {
-				scopes = append(scopes, models.TagScope(tagID))
-			} else {
-				scopes = append(scopes, models.AllScope)
-			}
-		}
+	if stream == nil {
+		// streams that do not exist are empty
+		stream = append(stream, models.NoneScope)
 	}
+	scopes := append([]func(*gorm.DB) *gorm.DB{models.UserScope(user.ID)}, stream...)
 
 	var state reader.State
 	switch params.Filter {
@@ -442,6 +403,10 @@ func listStreamItemIds(c *gin.Context) {
 	}
 	scopes = append(scopes, models.StateScope(state))
 
+	if params.Query != "" {
+		scopes = append(scopes, models.SearchScope(params.Query))
+	}
+
 	if params.StartTime != 0 {
 		scopes = append(scopes, models.StartTimeScope(time.Unix(params.StartTime, 0)))
 	}
@@ -454,31 +419,715 @@ func listStreamItemIds(c *gin.Context) {
 	}
 	scopes = append(scopes, models.CountScope(params.Count))
 
-	ids, count, err := models.ListEntryIDs(scopes...)
-	if err != nil {
-		c.JSON(routes.InternalServerError())
-		return
-	}
+	return scopes, nil
+}
 
-	var res reader.StreamItems
-	if len(ids) > 0 {
-		for _, id := range ids {
-			res.Items = append(res.Items, &reader.StreamIDItem{
-				ID: strconv.FormatInt(id, 10),
-			})
+// streamScopes generates query scopes selecting entries of stream for user, they apply after models.UserScope,
+// nil for streams that do not exist so that they never fall back to the whole reading list
+func streamScopes(user *models.User, streamID string) ([]func(*gorm.DB) *gorm.DB, error) {
+	switch {
+	case streamID == "user/-/state/com.google/reading-list":
+		return []func(*gorm.DB) *gorm.DB{models.AllScope}, nil
+	case streamID == "user/-/state/com.google/starred":
+		return []func(*gorm.DB) *gorm.DB{models.StarredScope}, nil
+	case strings.HasPrefix(streamID, "feed/"):
+		feedID, err := parseFeedStreamID(streamID[5:])
+		if err != nil || feedID == -1 {
+			return nil, err
 		}
 
-		if count > len(ids) {
-			res.Continuation = ids[len(ids)-1]
+		return []func(*gorm.DB) *gorm.DB{models.FeedScope(feedID)}, nil
+	case strings.HasPrefix(streamID, "user/-/label/"):
+		// names are stored escaped
+		name := html.EscapeString(streamID[13:])
+
+		category, err := models.GetCategoryForName(user.ID, name)
+		if err != nil {
+			return nil, err
+		}
+		if category != nil {
+			return []func(*gorm.DB) *gorm.DB{models.CategoryScope(category.ID)}, nil
 		}
-	}
 
-	c.JSON(http.StatusOK, res)
-}
+		tagID, err := models.GetTagIDForName(user.ID, name)
+		if err != nil {
+			return nil, err
+		}
+		if tagID != -1 {
+			return []func(*gorm.DB) *gorm.DB{models.TagScope(tagID)}, nil
+		}
 
-func listSubscription(c *gin.Context) {
-	categories, err := models.ListAllCategoriesWithFeeds()
-	if err != nil {
+		search, err := models.GetSavedSearchForName(user.ID, name)
+		if err != nil || search == nil {
+			return nil, err
+		}
+		compiled, err := compileSavedSearch(user, search)
+		if err != nil {
+			return nil, err
+		}
+		if compiled == nil {
+			return []func(*gorm.DB) *gorm.DB{models.NoneScope}, nil
+		}
+
+		return compiled.Scopes, nil
+	}
+
+	return nil, nil
+}
+
+// streamTitle returns the display title of stream for user
+func streamTitle(user *models.User, streamID string) (string, error) {
+	switch {
+	case streamID == "user/-/state/com.google/reading-list":
+		return "Reading list", nil
+	case streamID == "user/-/state/com.google/starred":
+		return "Starred", nil
+	case strings.HasPrefix(streamID, "feed/"):
+		feedID, err := parseFeedStreamID(streamID[5:])
+		if err != nil {
+			return "", err
+		}
+
+		subscription, err := models.GetSubscription(user.ID, feedID)
+		if err != nil {
+			return "", err
+		}
+		if subscription != nil {
+			return utils.EscapeToUnicodeAlternative(subscription.Name(), true), nil
+		}
+	case strings.HasPrefix(streamID, "user/-/label/"):
+		return streamID[13:], nil
+	}
+
+	return streamID, nil
+}
+
+// subscribeFeed subscribes user to feed of URL, the feed is added with name or URL if missing, returns the feed ID
+func subscribeFeed(user *models.User, url, name, website string) (int64, error) {
+	categoryID, err := feeds.SetupCategory(models.SharedUserID, feeds.UncategorizedCategoryName)
+	if err != nil {
+		return 0, err
+	}
+
+	feedID, err := models.GetFeedIDForURL(url)
+	if err != nil {
+		return 0, err
+	}
+	if feedID == -1 {
+		feedName := name
+		if feedName == "" {
+			feedName = url
+		}
+		if feedID, err = models.AddFeed(
+			utils.Truncate(html.EscapeString(feedName), 255),
+			int8(reader.PriorityMainStream),
+			url,
+			utils.Truncate(website, 255),
+			categoryID); err != nil {
+			return 0, err
+		}
+	}
+
+	subscription, err := models.GetSubscription(user.ID, feedID)
+	if err != nil {
+		return 0, err
+	}
+	if subscription == nil {
+		if _, err := models.AddSubscription(user.ID, feedID, categoryID, int8(reader.PriorityMainStream), ""); err != nil {
+			return 0, err
+		}
+	}
+
+	return feedID, nil
+}
+
+// unsubscribeFeed unsubscribes user from feed, the feed is deleted when nobody subscribes to it unless it is built in
+func unsubscribeFeed(user *models.User, feedID int64) error {
+	if err := models.DeleteSubscription(user.ID, feedID); err != nil {
+		return err
+	}
+
+	count, err := models.CountFeedSubscriptions(feedID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	feed, err := models.GetFeed(feedID)
+	if err != nil {
+		return err
+	}
+	if feed == nil {
+		return nil
+	}
+	if _, ok := feeds.Lookup(feed.URL); ok {
+		return nil
+	}
+
+	return models.DeleteFeed(feedID)
+}
+
+func disableTag(c *gin.Context) {
+	bodyPosts, user, ok := parseCheckedPostBody(c)
+	if !ok {
+		return
+	}
+
+	name := parseLabel(user, firstPostValue(bodyPosts, "s"))
+	if name == "" {
+		c.JSON(routes.InvalidParameterError("s"))
+		return
+	}
+	name = html.EscapeString(name)
+
+	category, err := models.GetCategoryForName(user.ID, name)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if category != nil {
+		if category.UserID == models.SharedUserID {
+			c.JSON(routes.ForbiddenError("tag"))
+			return
+		}
+
+		uncategorizedID, err := feeds.SetupCategory(models.SharedUserID, feeds.UncategorizedCategoryName)
+		if err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+		if err := models.DeleteCategory(category.ID, uncategorizedID); err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+
+		c.String(http.StatusOK, "OK")
+		return
+	}
+
+	tagID, err := models.GetTagIDForName(user.ID, name)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if tagID == -1 {
+		search, err := models.GetSavedSearchForName(user.ID, name)
+		if err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+		if search == nil {
+			c.JSON(routes.NotFoundError("tag"))
+			return
+		}
+
+		if _, err := models.DeleteSavedSearch(user.ID, search.ID); err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+
+		c.String(http.StatusOK, "OK")
+		return
+	}
+
+	if err := models.DeleteTag(user.ID, tagID); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+// editSubscriptionFeed applies title and label changes to subscription of user
+func editSubscriptionFeed(user *models.User, feedID int64, title, addLabel, removeLabel string) error {
+	if title != "" {
+		if err := models.UpdateSubscriptionTitle(user.ID, feedID, utils.Truncate(html.EscapeString(title), 255)); err != nil {
+			return err
+		}
+	}
+
+	if name := parseLabel(user, addLabel); name != "" {
+		categoryID, err := feeds.SetupCategory(user.ID, utils.Truncate(html.EscapeString(name), 255))
+		if err != nil {
+			return err
+		}
+		return models.UpdateSubscriptionCategory(user.ID, feedID, categoryID)
+	}
+
+	if name := parseLabel(user, removeLabel); name != "" {
+		category, err := models.GetCategoryForName(user.ID, html.EscapeString(name))
+		if err != nil {
+			return err
+		}
+
+		subscription, err := models.GetSubscription(user.ID, feedID)
+		if err != nil {
+			return err
+		}
+		if subscription == nil || category == nil || subscription.CategoryID != category.ID {
+			return nil
+		}
+
+		uncategorizedID, err := feeds.SetupCategory(models.SharedUserID, feeds.UncategorizedCategoryName)
+		if err != nil {
+			return err
+		}
+		return models.UpdateSubscriptionCategory(user.ID, feedID, uncategorizedID)
+	}
+
+	return nil
+}
+
+func editSubscription(c *gin.Context) {
+	bodyPosts, user, ok := parseCheckedPostBody(c)
+	if !ok {
+		return
+	}
+
+	action := firstPostValue(bodyPosts, "ac")
+	title := firstPostValue(bodyPosts, "t")
+	addLabel := firstPostValue(bodyPosts, "a")
+	removeLabel := firstPostValue(bodyPosts, "r")
+
+	streamIDs, ok := bodyPosts["s"]
+	if !ok {
+		c.JSON(routes.InvalidParameterError("s"))
+		return
+	}
+
+	for _, streamID := range streamIDs {
+		if !strings.HasPrefix(streamID, "feed/") {
+			c.JSON(routes.InvalidParameterError("s"))
+			return
+		}
+		streamID = streamID[5:]
+
+		switch action {
+		case "subscribe":
+			if streamID == "" || len(streamID) > 255 {
+				c.JSON(routes.InvalidParameterError("s"))
+				return
+			}
+
+			feedID, err := subscribeFeed(user, streamID, title, "")
+			if err != nil {
+				c.JSON(routes.InternalServerError())
+				return
+			}
+
+			if err := editSubscriptionFeed(user, feedID, title, addLabel, removeLabel); err != nil {
+				c.JSON(routes.InternalServerError())
+				return
+			}
+		case "unsubscribe":
+			feedID, err := parseFeedStreamID(streamID)
+			if err != nil {
+				c.JSON(routes.InternalServerError())
+				return
+			}
+			if feedID == -1 {
+				c.JSON(routes.NotFoundError("feed"))
+				return
+			}
+
+			if err := unsubscribeFeed(user, feedID); err != nil {
+				c.JSON(routes.InternalServerError())
+				return
+			}
+		case "edit":
+			feedID, err := parseFeedStreamID(streamID)
+			if err != nil {
+				c.JSON(routes.InternalServerError())
+				return
+			}
+			if feedID == -1 {
+				c.JSON(routes.NotFoundError("feed"))
+				return
+			}
+
+			subscription, err := models.GetSubscription(user.ID, feedID)
+			if err != nil {
+				c.JSON(routes.InternalServerError())
+				return
+			}
+			if subscription == nil {
+				c.JSON(routes.NotFoundError("feed"))
+				return
+			}
+
+			if err := editSubscriptionFeed(user, feedID, title, addLabel, removeLabel); err != nil {
+				c.JSON(routes.InternalServerError())
+				return
+			}
+		default:
+			c.JSON(routes.InvalidParameterError("ac"))
+			return
+		}
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+func editTag(c *gin.Context) {
+	bodyPosts, user, ok := parseCheckedPostBody(c)
+	if !ok {
+		return
+	}
+
+	addTag := firstPostValue(bodyPosts, "a")
+	removeTag := firstPostValue(bodyPosts, "r")
+
+	ids, ok := bodyPosts["i"]
+	if !ok {
+		c.JSON(routes.InvalidParameterError("i"))
+		return
+	}
+
+	var entryIDs []int64
+	for _, id := range ids {
+		_id, err := parseEntryID(id)
+		if err != nil {
+			c.JSON(routes.InvalidParameterError("i"))
+			return
+		}
+
+		entryIDs = append(entryIDs, _id)
+	}
+
+	switch addTag {
+	case "user/-/state/com.google/read":
+		if _, err := models.MarkRead(user.ID, entryIDs, true); err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+	case "user/-/state/com.google/starred":
+		if _, err := models.MarkStarred(user.ID, entryIDs, true); err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+	default:
+		if tagName := parseLabel(user, addTag); tagName != "" {
+			tagName = html.EscapeString(tagName)
+			tagID, err := models.GetTagIDForName(user.ID, tagName)
+			if err != nil {
+				c.JSON(routes.InternalServerError())
+				return
+			}
+			if tagID == -1 {
+				_id, err := models.AddTag(user.ID, tagName)
+				if err != nil {
+					c.JSON(routes.InternalServerError())
+					return
+				}
+				tagID = _id
+			}
+			if err := models.AddTagForEntries(tagID, entryIDs); err != nil {
+				c.JSON(routes.InternalServerError())
+				return
+			}
+		}
+	}
+
+	switch removeTag {
+	case "user/-/state/com.google/read":
+		if _, err := models.MarkRead(user.ID, entryIDs, false); err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+	case "user/-/state/com.google/starred":
+		if _, err := models.MarkStarred(user.ID, entryIDs, false); err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+	default:
+		if tagName := parseLabel(user, removeTag); tagName != "" {
+			tagID, err := models.GetTagIDForName(user.ID, html.EscapeString(tagName))
+			if err != nil {
+				c.JSON(routes.InternalServerError())
+				return
+			}
+			if tagID != -1 {
+				if err := models.RemoveTagForEntries(tagID, entryIDs); err != nil {
+					c.JSON(routes.InternalServerError())
+					return
+				}
+			}
+		}
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+func exportSubscription(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := opml.Export(&buf, user.ID); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\"subscriptions.opml\"")
+	c.Data(http.StatusOK, "text/x-opml; charset=utf-8", buf.Bytes())
+}
+
+// importSubscription imports OPML document of body, the action token T is taken from query since the body is OPML
+func importSubscription(c *gin.Context) {
+	token, ok := c.GetQuery("T")
+	if !ok {
+		c.JSON(routes.InvalidParameterError("T"))
+		return
+	}
+	user, ok := checkActionToken(c, token)
+	if !ok {
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, importMaxBodySize))
+	if err != nil {
+		c.JSON(routes.InvalidParameterError("OPML"))
+		return
+	}
+
+	if _, err := opml.Import(bytes.NewReader(body), user.ID); err != nil {
+		if errors.Is(err, opml.ErrInvalidDocument) {
+			c.JSON(routes.InvalidParameterError("OPML"))
+			return
+		}
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+func listStreamContents(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	params := parseStreamParams(c)
+
+	streamID := strings.TrimPrefix(c.Param("streamId"), "/")
+	if streamID == "" {
+		streamID = c.Request.URL.Query().Get("s")
+	}
+	if streamID == "" {
+		streamID = "user/-/state/com.google/reading-list"
+	}
+
+	scopes, err := streamItemScopes(user, streamID, params)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	ids, count, err := models.ListEntryIDs(scopes...)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	entries, err := models.ListEntriesByIDs(user.ID, ids, params.Order)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	items, err := streamContentItems(user, entries)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	title, err := streamTitle(user, streamID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	res := &reader.StreamContents{
+		ID:      streamID,
+		Items:   items,
+		Title:   title,
+		Updated: time.Now().Unix(),
+	}
+	if res.Items == nil {
+		res.Items = []*reader.StreamContentItem{}
+	}
+	if count > len(ids) {
+		res.Continuation = strconv.FormatInt(ids[len(ids)-1], 10)
+	}
+
+	render(c, &streamResponse{res})
+}
+
+func listStreamItemContents(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	params := parseStreamParams(c)
+
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	bodyPosts, err := parsePostBody(string(body))
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	ids, ok := bodyPosts["i"]
+	if !ok {
+		c.JSON(routes.InvalidParameterError("i"))
+		return
+	}
+
+	var entryIDs []int64
+	for _, id := range ids {
+		_id, err := parseEntryID(id)
+		if err != nil {
+			c.JSON(routes.InvalidParameterError("i"))
+			return
+		}
+
+		entryIDs = append(entryIDs, _id)
+	}
+
+	entries, err := models.ListEntriesByIDs(user.ID, entryIDs, params.Order)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	items, err := streamContentItems(user, entries)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	render(c, &streamResponse{&reader.StreamContents{
+		ID:      "user/-/state/com.google/reading-list",
+		Items:   items,
+		Updated: time.Now().Unix(),
+	}})
+}
+
+func listStreamItemIds(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	params := parseStreamParams(c)
+
+	streamID := c.Request.URL.Query().Get("s")
+	if streamID == "" {
+		c.JSON(routes.InvalidParameterError("s"))
+		return
+	}
+
+	scopes, err := streamItemScopes(user, streamID, params)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	ids, count, err := models.ListEntryIDs(scopes...)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	var res reader.StreamItems
+	if len(ids) > 0 {
+		for _, id := range ids {
+			res.Items = append(res.Items, &reader.StreamIDItem{
+				ID: strconv.FormatInt(id, 10),
+			})
+		}
+
+		if count > len(ids) {
+			res.Continuation = ids[len(ids)-1]
+		}
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// searchItemIds lists IDs of entries in stream matching the full-text query q, num is an alias of n
+func searchItemIds(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	params := parseStreamParams(c)
+	if params.Query == "" {
+		c.JSON(routes.InvalidParameterError("q"))
+		return
+	}
+	if v := c.Request.URL.Query().Get("num"); v != "" {
+		if v, err := strconv.Atoi(v); err == nil {
+			params.Count = v
+		}
+	}
+
+	streamID := c.Request.URL.Query().Get("s")
+	if streamID == "" {
+		streamID = "user/-/state/com.google/reading-list"
+	}
+
+	scopes, err := streamItemScopes(user, streamID, params)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	ids, count, err := models.ListEntryIDs(scopes...)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	res := reader.SearchItems{Results: []*reader.StreamIDItem{}}
+	for _, id := range ids {
+		res.Results = append(res.Results, &reader.StreamIDItem{
+			ID: strconv.FormatInt(id, 10),
+		})
+	}
+	if count > len(ids) {
+		res.Continuation = ids[len(ids)-1]
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func listSubscription(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	categories, err := models.ListAllCategoriesWithFeeds(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	searchLabels, err := savedSearchLabels(user)
+	if err != nil {
 		c.JSON(routes.InternalServerError())
 		return
 	}
@@ -487,35 +1136,356 @@ func listSubscription(c *gin.Context) {
 
 	var subscriptions []*Feed
 	for _, category := range categories {
-		for _, feed := range category.Feeds {
+		for _, subscription := range category.Subscriptions {
+			feed := subscription.Feed
 			categoryName := html.UnescapeString(category.Name)
 
-			subscriptions = append(subscriptions, &Feed{
-				ID: fmt.Sprintf("feed/%d", feed.ID),
-				Categories: []*Category{
-					{
-						ID:    fmt.Sprintf("user/-/label/%s", categoryName),
-						Label: categoryName,
-					},
+			feedCategories := []*Category{
+				{
+					ID:    fmt.Sprintf("user/-/label/%s", categoryName),
+					Label: categoryName,
 				},
-				HTMLURL: html.UnescapeString(feed.Website),
-				IconURL: "Feed IconURL",
-				Title:   utils.EscapeToUnicodeAlternative(feed.Name, true),
-				URL:     html.UnescapeString(feed.URL),
+			}
+			// saved searches are listed under the feeds they are restricted to, or all feeds
+			for _, label := range searchLabels {
+				if (label.compiled.FeedID != 0 && label.compiled.FeedID != feed.ID) ||
+					(label.compiled.CategoryID != 0 && label.compiled.CategoryID != category.ID) {
+					continue
+				}
+				feedCategories = append(feedCategories, &Category{
+					ID:    fmt.Sprintf("user/-/label/%s", label.name),
+					Label: label.name,
+				})
+			}
+
+			subscriptions = append(subscriptions, &Feed{
+				ID:         fmt.Sprintf("feed/%d", feed.ID),
+				Categories: feedCategories,
+				HTMLURL:    html.UnescapeString(feed.Website),
+				IconURL:    "Feed IconURL",
+				Title:      utils.EscapeToUnicodeAlternative(subscription.Name(), true),
+				URL:        html.UnescapeString(feed.URL),
 			})
 		}
 	}
 
-	output := c.Request.URL.Query().Get("output")
-	switch output {
-	case "":
-		fallthrough
-	case "json":
-		c.JSON(http.StatusOK, gin.H{
-			"subscriptions": subscriptions,
+	render(c, gin.H{
+		"subscriptions": subscriptions,
+	})
+}
+
+func listTags(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	categories, err := models.ListCategories(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	tags, err := models.ListTags(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	categoryCounts, err := models.CountUnreadByCategory(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	tagCounts, err := models.CountUnreadByTag(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	searchLabels, err := savedSearchLabels(user)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	unreadCounts := func(counts []*models.UnreadCount) map[int64]int64 {
+		m := make(map[int64]int64, len(counts))
+		for _, count := range counts {
+			m[count.ID] = count.Count
+		}
+		return m
+	}
+	categoryUnreadCounts := unreadCounts(categoryCounts)
+	tagUnreadCounts := unreadCounts(tagCounts)
+
+	list := []*Tag{
+		{
+			ID: "user/-/state/com.google/starred",
+		},
+	}
+	for _, category := range categories {
+		count := categoryUnreadCounts[category.ID]
+		list = append(list, &Tag{
+			ID:          fmt.Sprintf("user/-/label/%s", html.UnescapeString(category.Name)),
+			Type:        "folder",
+			UnreadCount: &count,
+		})
+	}
+	for _, tag := range tags {
+		count := tagUnreadCounts[tag.ID]
+		list = append(list, &Tag{
+			ID:          fmt.Sprintf("user/-/label/%s", html.UnescapeString(tag.Name)),
+			Type:        "tag",
+			UnreadCount: &count,
+		})
+	}
+	for _, label := range searchLabels {
+		count, err := models.CountUnreadForScopes(user.ID, label.compiled.Scopes...)
+		if err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+		list = append(list, &Tag{
+			ID:          fmt.Sprintf("user/-/label/%s", label.name),
+			Type:        "folder",
+			UnreadCount: &count.Count,
 		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tags": list,
+	})
+}
+
+func markAllAsRead(c *gin.Context) {
+	bodyPosts, user, ok := parseCheckedPostBody(c)
+	if !ok {
+		return
+	}
+
+	streamID := firstPostValue(bodyPosts, "s")
+	if streamID == "" {
+		c.JSON(routes.InvalidParameterError("s"))
+		return
+	}
+
+	before := time.Now()
+	if v := utils.Trim(firstPostValue(bodyPosts, "ts")); v != "" {
+		ts, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(routes.InvalidParameterError("ts"))
+			return
+		}
+		before = time.UnixMicro(ts)
+	}
+
+	scopes, err := streamScopes(user, streamID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if scopes == nil {
+		c.JSON(routes.NotFoundError("stream"))
+		return
+	}
+	scopes = append(scopes, models.StopTimeScope(before))
+
+	if _, err := models.MarkReadForScopes(user.ID, scopes...); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+func quickAddSubscription(c *gin.Context) {
+	bodyPosts, user, ok := parseCheckedPostBody(c)
+	if !ok {
+		return
+	}
+
+	query := firstPostValue(bodyPosts, "quickadd")
+	if query == "" {
+		query = c.Request.URL.Query().Get("quickadd")
+	}
+	query = strings.TrimPrefix(utils.Trim(query), "feed/")
+	if query == "" {
+		c.JSON(routes.InvalidParameterError("quickadd"))
+		return
+	}
+
+	result := &QuickAddResult{
+		Query: query,
+	}
+
+	discovery, err := generic.Discover(c.Request.Context(), query)
+	if err != nil || len(discovery.URL) > 255 {
+		result.Error = "no feed found"
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	feedID, err := subscribeFeed(user, discovery.URL, discovery.Title, discovery.Website)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	result.NumResults = 1
+	result.StreamID = fmt.Sprintf("feed/%d", feedID)
+	result.StreamName = discovery.Title
+
+	c.JSON(http.StatusOK, result)
+}
+
+func renameTag(c *gin.Context) {
+	bodyPosts, user, ok := parseCheckedPostBody(c)
+	if !ok {
+		return
+	}
+
+	name := parseLabel(user, firstPostValue(bodyPosts, "s"))
+	if name == "" {
+		c.JSON(routes.InvalidParameterError("s"))
+		return
+	}
+	name = html.EscapeString(name)
+
+	dest := parseLabel(user, firstPostValue(bodyPosts, "dest"))
+	if dest == "" {
+		c.JSON(routes.InvalidParameterError("dest"))
+		return
+	}
+	dest = html.EscapeString(dest)
+
+	category, err := models.GetCategoryForName(user.ID, name)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if category != nil {
+		if category.UserID == models.SharedUserID {
+			c.JSON(routes.ForbiddenError("tag"))
+			return
+		}
+
+		if err := models.RenameCategory(user.ID, category.ID, utils.Truncate(dest, 255)); err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+
+		c.String(http.StatusOK, "OK")
+		return
+	}
+
+	tagID, err := models.GetTagIDForName(user.ID, name)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	if tagID == -1 {
+		search, err := models.GetSavedSearchForName(user.ID, name)
+		if err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+		if search == nil {
+			c.JSON(routes.NotFoundError("tag"))
+			return
+		}
+
+		if err := models.UpdateSavedSearch(user.ID, search.ID, utils.Truncate(dest, 63), search.Query); err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+
+		c.String(http.StatusOK, "OK")
+		return
+	}
+
+	if err := models.RenameTag(user.ID, tagID, utils.Truncate(dest, 63)); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+func unreadCount(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	feedCounts, err := models.CountUnreadByFeed(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	categoryCounts, err := models.CountUnreadByCategory(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	tagCounts, err := models.CountUnreadByTag(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	searchLabels, err := savedSearchLabels(user)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
 		return
-	default:
-		c.JSON(routes.InvalidParameterError("output"))
 	}
+
+	newUnreadCount := func(id string, count int64, newest time.Time) *UnreadCount {
+		return &UnreadCount{
+			ID:                      id,
+			Count:                   count,
+			NewestItemTimestampUSec: strconv.FormatInt(newest.UnixMicro(), 10),
+		}
+	}
+
+	var total int64
+	var newest time.Time
+	var counts []*UnreadCount
+
+	for _, count := range feedCounts {
+		total += count.Count
+		if count.Newest.After(newest) {
+			newest = count.Newest
+		}
+		counts = append(counts, newUnreadCount(fmt.Sprintf("feed/%d", count.ID), count.Count, count.Newest))
+	}
+	for _, count := range categoryCounts {
+		id := fmt.Sprintf("user/-/label/%s", html.UnescapeString(count.Name))
+		counts = append(counts, newUnreadCount(id, count.Count, count.Newest))
+	}
+	for _, count := range tagCounts {
+		id := fmt.Sprintf("user/-/label/%s", html.UnescapeString(count.Name))
+		counts = append(counts, newUnreadCount(id, count.Count, count.Newest))
+	}
+	for _, label := range searchLabels {
+		count, err := models.CountUnreadForScopes(user.ID, label.compiled.Scopes...)
+		if err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+		id := fmt.Sprintf("user/-/label/%s", label.name)
+		counts = append(counts, newUnreadCount(id, count.Count, count.Newest))
+	}
+	if newest.IsZero() {
+		newest = time.Now()
+	}
+	counts = append(counts, newUnreadCount("user/-/state/com.google/reading-list", total, newest))
+
+	c.JSON(http.StatusOK, gin.H{
+		"max":          total,
+		"unreadcounts": counts,
+	})
 }
@@ -16,18 +16,123 @@ func SetupRoutes(router *gin.Engine) {
 		rvReader := rv.Group("reader/api/0")
 		rvReader.Use(checkAuth())
 		{
+			rvReader.GET("auth-token/list", listAuthTokens)
+			rvReader.POST("auth-token/revoke", revokeAuthToken)
+
+			rvReader.POST("disable-tag", disableTag)
 			rvReader.POST("edit-tag", editTag)
+			rvReader.POST("rename-tag", renameTag)
+
+			rvReader.POST("mark-all-as-read", markAllAsRead)
 
+			rvReader.GET("search/items/ids", searchItemIds)
+
+			rvReader.GET("stream/contents/*streamId", listStreamContents)
 			rvReader.POST("stream/items/contents", listStreamItemContents)
 			rvReader.GET("stream/items/ids", listStreamItemIds)
 
+			rvReader.POST("subscription/edit", editSubscription)
+			rvReader.GET("subscription/export", exportSubscription)
+			rvReader.POST("subscription/import", importSubscription)
 			rvReader.GET("subscription/list", listSubscription)
+			rvReader.POST("subscription/quickadd", quickAddSubscription)
+
+			rvReader.GET("tag/list", listTags)
 
 			rvReader.GET("token", token)
 
+			rvReader.GET("unread-count", unreadCount)
+
 			rvReader.GET("user-info", userInfo)
 		}
 	}
 
+	rf := router.Group("api/fever.php")
+	rf.Use(checkFeverAuth())
+	{
+		rf.GET("", fever)
+		rf.POST("", fever)
+	}
+
+	rn := router.Group("index.php/apps/news/api/v1-3")
+	rn.Use(checkBasicAuth())
+	{
+		rn.GET("folders", listNewsFolders)
+
+		rn.GET("feeds", listNewsFeeds)
+
+		rn.GET("items", listNewsItems)
+		rn.GET("items/updated", listNewsUpdatedItems)
+		rn.PUT("items/:itemId/read", markNewsItem(true, true))
+		rn.PUT("items/:itemId/unread", markNewsItem(true, false))
+		rn.PUT("items/:itemId/star", markNewsItem(false, true))
+		rn.PUT("items/:itemId/unstar", markNewsItem(false, false))
+		rn.PUT("items/read/multiple", markNewsItems(true, true))
+		rn.PUT("items/unread/multiple", markNewsItems(true, false))
+		rn.PUT("items/star/multiple", markNewsItems(false, true))
+		rn.PUT("items/unstar/multiple", markNewsItems(false, false))
+
+		rn.GET("version", newsVersion)
+	}
+
+	router.GET("api/v1/openapi.yaml", openAPI)
+	router.GET("api/v1/events", checkEventsAuth(), apiEvents)
+	ra := router.Group("api/v1")
+	ra.Use(checkBearerAuth())
+	{
+		ra.GET("categories", apiListCategories)
+		ra.POST("categories", apiCreateCategory)
+		ra.PATCH("categories/:id", apiUpdateCategory)
+		ra.DELETE("categories/:id", apiDeleteCategory)
+
+		ra.GET("entries", apiListEntries)
+		ra.PATCH("entries", apiUpdateEntries)
+		ra.GET("entries/:id", apiGetEntry)
+		ra.PATCH("entries/:id", apiUpdateEntry)
+		ra.PUT("entries/:id/tags/:tagId", apiEditEntryTag(true))
+		ra.DELETE("entries/:id/tags/:tagId", apiEditEntryTag(false))
+
+		ra.POST("events/tickets", apiCreateEventsTicket)
+
+		ra.GET("feeds", apiListFeeds)
+		ra.POST("feeds", apiCreateFeed)
+		ra.GET("feeds/:id", apiGetFeed)
+		ra.PATCH("feeds/:id", apiUpdateFeed)
+		ra.DELETE("feeds/:id", apiDeleteFeed)
+
+		ra.GET("me", apiMe)
+
+		ra.GET("outputs", apiListOutputs)
+		ra.POST("outputs", apiCreateOutput)
+		ra.DELETE("outputs/:id", apiDeleteOutput)
+
+		ra.GET("rules", apiListRules)
+		ra.POST("rules", apiCreateRule)
+		ra.POST("rules/dry-run", apiDryRunRuleInput)
+		ra.GET("rules/:id", apiGetRule)
+		ra.PATCH("rules/:id", apiUpdateRule)
+		ra.DELETE("rules/:id", apiDeleteRule)
+		ra.GET("rules/:id/dry-run", apiDryRunRule)
+
+		ra.GET("searches", apiListSavedSearches)
+		ra.POST("searches", apiCreateSavedSearch)
+		ra.PATCH("searches/:id", apiUpdateSavedSearch)
+		ra.DELETE("searches/:id", apiDeleteSavedSearch)
+
+		ra.GET("tags", apiListTags)
+		ra.POST("tags", apiCreateTag)
+		ra.PATCH("tags/:id", apiUpdateTag)
+		ra.DELETE("tags/:id", apiDeleteTag)
+
+		ra.GET("webhooks", apiListWebhooks)
+		ra.POST("webhooks", apiCreateWebhook)
+		ra.GET("webhooks/:id", apiGetWebhook)
+		ra.PATCH("webhooks/:id", apiUpdateWebhook)
+		ra.DELETE("webhooks/:id", apiDeleteWebhook)
+		ra.GET("webhooks/:id/deliveries", apiListWebhookDeliveries)
+	}
+
+	router.GET("output/:file", renderOutput)
+
 	router.GET("ping", ping)
 }
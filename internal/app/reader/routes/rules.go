@@ -0,0 +1,304 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"reader/internal/app/reader/models"
+	"reader/internal/app/reader/rules"
+	"reader/internal/pkg/routes"
+)
+
+const apiDefaultDryRunLimit = 200
+
+// APIRule REST API rule
+type APIRule struct {
+	ID         int64                   `json:"id"`
+	Actions    []*models.RuleAction    `json:"actions"`
+	Conditions []*models.RuleCondition `json:"conditions"`
+	CreatedAt  time.Time               `json:"createdAt"`
+	Enabled    bool                    `json:"enabled"`
+	Name       string                  `json:"name"`
+}
+
+// APIRuleInput REST API rule create and update body, omitted fields are kept on update
+type APIRuleInput struct {
+	Actions    []*models.RuleAction    `json:"actions"`
+	Conditions []*models.RuleCondition `json:"conditions"`
+	Enabled    *bool                   `json:"enabled"`
+	Name       *string                 `json:"name"`
+}
+
+// APIDryRun REST API dry run result
+type APIDryRun struct {
+	Entries []*APIEntry `json:"entries"`
+	Scanned int         `json:"scanned"`
+}
+
+func apiRule(rule *models.Rule) *APIRule {
+	return &APIRule{
+		ID:         rule.ID,
+		Actions:    rule.Actions,
+		Conditions: rule.Conditions,
+		CreatedAt:  rule.CreatedAt,
+		Enabled:    rule.Enabled,
+		Name:       rule.Name,
+	}
+}
+
+// applyRuleInput validates input and applies it to rule, the error response is written on failure
+func applyRuleInput(c *gin.Context, user *models.User, input *APIRuleInput, rule *models.Rule) bool {
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" || len(name) > 63 {
+			c.JSON(routes.InvalidParameterError("name"))
+			return false
+		}
+		rule.Name = name
+	}
+	if input.Enabled != nil {
+		rule.Enabled = *input.Enabled
+	}
+	if input.Conditions != nil {
+		rule.Conditions = input.Conditions
+	}
+	if input.Actions != nil {
+		rule.Actions = input.Actions
+	}
+
+	if _, err := rules.Compile(rule); err != nil {
+		if errors.Is(err, rules.ErrInvalidAction) {
+			c.JSON(routes.InvalidParameterError("actions"))
+		} else {
+			c.JSON(routes.InvalidParameterError("conditions"))
+		}
+		return false
+	}
+
+	if input.Actions != nil {
+		for _, action := range rule.Actions {
+			if action.Type != models.RuleActionTag {
+				action.TagID = 0
+				continue
+			}
+
+			tag, err := models.GetTag(user.ID, action.TagID)
+			if err != nil {
+				c.JSON(routes.InternalServerError())
+				return false
+			}
+			if tag == nil {
+				c.JSON(routes.NotFoundError("tag"))
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// contextRule gets the rule of user in path, the error response is written on failure
+func contextRule(c *gin.Context, user *models.User) (*models.Rule, bool) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	rule, err := models.GetRule(user.ID, id)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return nil, false
+	}
+	if rule == nil {
+		c.JSON(routes.NotFoundError("rule"))
+		return nil, false
+	}
+
+	return rule, true
+}
+
+// dryRunRule writes the entries of user which rule would match, the newest entries up to limit are evaluated
+func dryRunRule(c *gin.Context, user *models.User, rule *models.Rule) {
+	limit := apiDefaultDryRunLimit
+	if v, ok := c.GetQuery("limit"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > rules.MaxDryRunLimit {
+			c.JSON(routes.InvalidParameterError("limit"))
+			return
+		}
+		limit = n
+	}
+
+	ids, scanned, err := rules.DryRun(rule, limit)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	entries, err := apiEntries(user, ids, false)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.JSON(http.StatusOK, &APIDryRun{
+		Entries: entries,
+		Scanned: scanned,
+	})
+}
+
+func apiListRules(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	list, err := models.ListRules(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	res := []*APIRule{}
+	for _, rule := range list {
+		res = append(res, apiRule(rule))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules": res,
+	})
+}
+
+func apiCreateRule(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	var input APIRuleInput
+	if !bindAPIJSON(c, &input) {
+		return
+	}
+	if input.Name == nil {
+		c.JSON(routes.InvalidParameterError("name"))
+		return
+	}
+
+	rule := &models.Rule{
+		Enabled: true,
+		UserID:  user.ID,
+	}
+	if !applyRuleInput(c, user, &input, rule) {
+		return
+	}
+
+	if _, err := models.AddRule(rule); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("%s/rules/%d", apiPrefix, rule.ID))
+	c.JSON(http.StatusCreated, apiRule(rule))
+}
+
+func apiDryRunRuleInput(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	var input APIRuleInput
+	if !bindAPIJSON(c, &input) {
+		return
+	}
+
+	rule := &models.Rule{
+		Enabled: true,
+		UserID:  user.ID,
+	}
+	if !applyRuleInput(c, user, &input, rule) {
+		return
+	}
+
+	dryRunRule(c, user, rule)
+}
+
+func apiGetRule(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	rule, ok := contextRule(c, user)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, apiRule(rule))
+}
+
+func apiUpdateRule(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	rule, ok := contextRule(c, user)
+	if !ok {
+		return
+	}
+
+	var input APIRuleInput
+	if !bindAPIJSON(c, &input) {
+		return
+	}
+	if !applyRuleInput(c, user, &input, rule) {
+		return
+	}
+
+	if err := models.UpdateRule(rule); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.JSON(http.StatusOK, apiRule(rule))
+}
+
+func apiDeleteRule(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	rule, ok := contextRule(c, user)
+	if !ok {
+		return
+	}
+
+	if _, err := models.DeleteRule(user.ID, rule.ID); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func apiDryRunRule(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	rule, ok := contextRule(c, user)
+	if !ok {
+		return
+	}
+
+	dryRunRule(c, user, rule)
+}
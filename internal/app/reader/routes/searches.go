@@ -0,0 +1,234 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"reader/internal/app/reader/filter"
+	"reader/internal/app/reader/models"
+	"reader/internal/pkg/routes"
+)
+
+// APISavedSearch REST API saved search
+type APISavedSearch struct {
+	ID          int64     `json:"id"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Name        string    `json:"name"`
+	Query       string    `json:"query"`
+	StreamID    string    `json:"streamId"`
+	UnreadCount *int64    `json:"unreadCount"` // nil if a feed, category or tag of query no longer exists
+}
+
+// APISavedSearchInput REST API saved search create and update body
+type APISavedSearchInput struct {
+	Name  string `json:"name" binding:"required"`
+	Query string `json:"query" binding:"required"`
+}
+
+func apiSavedSearch(search *models.SavedSearch, unreadCount *int64) *APISavedSearch {
+	name := html.UnescapeString(search.Name)
+	return &APISavedSearch{
+		ID:          search.ID,
+		CreatedAt:   search.CreatedAt,
+		Name:        name,
+		Query:       search.Query,
+		StreamID:    fmt.Sprintf("user/-/label/%s", name),
+		UnreadCount: unreadCount,
+	}
+}
+
+// checkSavedSearchInput validates input of saved search of user, returns the escaped name,
+// the error response is written on failure
+func checkSavedSearchInput(c *gin.Context, user *models.User, input *APISavedSearchInput, id int64) (string, bool) {
+	name := html.EscapeString(strings.TrimSpace(input.Name))
+	if name == "" || len(name) > 63 {
+		c.JSON(routes.InvalidParameterError("name"))
+		return "", false
+	}
+
+	query := strings.TrimSpace(input.Query)
+	if query == "" || len(query) > 1023 {
+		c.JSON(routes.InvalidParameterError("query"))
+		return "", false
+	}
+	f, err := filter.Parse(query)
+	if err != nil {
+		c.JSON(routes.InvalidParameterError("query"))
+		return "", false
+	}
+	if _, err := f.Compile(user.ID, time.Now()); err != nil {
+		if errors.Is(err, filter.ErrUnresolved) {
+			c.JSON(routes.InvalidParameterError("query"))
+		} else {
+			c.JSON(routes.InternalServerError())
+		}
+		return "", false
+	}
+	input.Query = query
+
+	// names share the label namespace with categories and tags
+	category, err := models.GetCategoryForName(user.ID, name)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return "", false
+	}
+	tagID, err := models.GetTagIDForName(user.ID, name)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return "", false
+	}
+	search, err := models.GetSavedSearchForName(user.ID, name)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return "", false
+	}
+	if category != nil || tagID != -1 || (search != nil && search.ID != id) {
+		c.JSON(routes.ConflictError("search"))
+		return "", false
+	}
+
+	return name, true
+}
+
+// contextSavedSearch gets the saved search of user in path, the error response is written on failure
+func contextSavedSearch(c *gin.Context, user *models.User) (*models.SavedSearch, bool) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	search, err := models.GetSavedSearch(user.ID, id)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return nil, false
+	}
+	if search == nil {
+		c.JSON(routes.NotFoundError("search"))
+		return nil, false
+	}
+
+	return search, true
+}
+
+func apiListSavedSearches(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	searches, err := models.ListSavedSearches(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	list := []*APISavedSearch{}
+	for _, search := range searches {
+		compiled, err := compileSavedSearch(user, search)
+		if err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+
+		var unreadCount *int64
+		if compiled != nil {
+			count, err := models.CountUnreadForScopes(user.ID, compiled.Scopes...)
+			if err != nil {
+				c.JSON(routes.InternalServerError())
+				return
+			}
+			unreadCount = &count.Count
+		}
+		list = append(list, apiSavedSearch(search, unreadCount))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"searches": list,
+	})
+}
+
+func apiCreateSavedSearch(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	var input APISavedSearchInput
+	if !bindAPIJSON(c, &input) {
+		return
+	}
+	name, ok := checkSavedSearchInput(c, user, &input, 0)
+	if !ok {
+		return
+	}
+
+	id, err := models.AddSavedSearch(user.ID, name, input.Query)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	search, err := models.GetSavedSearch(user.ID, id)
+	if err != nil || search == nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("%s/searches/%d", apiPrefix, id))
+	c.JSON(http.StatusCreated, apiSavedSearch(search, nil))
+}
+
+func apiUpdateSavedSearch(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	search, ok := contextSavedSearch(c, user)
+	if !ok {
+		return
+	}
+
+	var input APISavedSearchInput
+	if !bindAPIJSON(c, &input) {
+		return
+	}
+	name, ok := checkSavedSearchInput(c, user, &input, search.ID)
+	if !ok {
+		return
+	}
+
+	if err := models.UpdateSavedSearch(user.ID, search.ID, name, input.Query); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+	search.Name = name
+	search.Query = input.Query
+
+	c.JSON(http.StatusOK, apiSavedSearch(search, nil))
+}
+
+func apiDeleteSavedSearch(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	search, ok := contextSavedSearch(c, user)
+	if !ok {
+		return
+	}
+
+	if _, err := models.DeleteSavedSearch(user.ID, search.ID); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -4,8 +4,27 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"reader/internal/pkg/routes"
 )
 
+// paramError invalid request parameter
+type paramError string
+
+func (e paramError) Error() string {
+	return string(e)
+}
+
+// writeError writes the error response, invalid parameter for paramError
+func writeError(c *gin.Context, err error) {
+	if target, ok := err.(paramError); ok {
+		c.JSON(routes.InvalidParameterError(string(target)))
+		return
+	}
+
+	c.JSON(routes.InternalServerError())
+}
+
 func ping(c *gin.Context) {
 	c.String(http.StatusOK, "pong")
 }
@@ -0,0 +1,345 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"reader/internal/app/reader/models"
+	"reader/internal/pkg/routes"
+	"reader/internal/pkg/utils"
+)
+
+const (
+	apiDefaultDeliveryLimit = 50
+	apiMaxDeliveryLimit     = 200
+)
+
+// APIWebhook REST API webhook, secret is returned on creation only
+type APIWebhook struct {
+	ID         int64     `json:"id"`
+	CategoryID *int64    `json:"categoryId"`
+	CreatedAt  time.Time `json:"createdAt"`
+	Enabled    bool      `json:"enabled"`
+	FeedID     *int64    `json:"feedId"`
+	Keyword    string    `json:"keyword"`
+	Secret     string    `json:"secret,omitempty"`
+	TagID      *int64    `json:"tagId"`
+	URL        string    `json:"url"`
+}
+
+// APIWebhookInput REST API webhook create and update body, filters of 0 are cleared on update
+type APIWebhookInput struct {
+	CategoryID *int64  `json:"categoryId"`
+	Enabled    *bool   `json:"enabled"`
+	FeedID     *int64  `json:"feedId"`
+	Keyword    *string `json:"keyword"`
+	Secret     *string `json:"secret"`
+	TagID      *int64  `json:"tagId"`
+	URL        *string `json:"url"`
+}
+
+// APIWebhookDelivery REST API webhook delivery log entry
+type APIWebhookDelivery struct {
+	ID             int64      `json:"id"`
+	Attempts       int32      `json:"attempts"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt"`
+	EntryID        int64      `json:"entryId"`
+	Event          string     `json:"event"`
+	LastAttemptAt  *time.Time `json:"lastAttemptAt"`
+	LastError      string     `json:"lastError"`
+	NextAttemptAt  *time.Time `json:"nextAttemptAt"` // pending deliveries only
+	ResponseStatus int        `json:"responseStatus"`
+	Status         string     `json:"status"`
+}
+
+func apiWebhook(webhook *models.Webhook) *APIWebhook {
+	return &APIWebhook{
+		ID:         webhook.ID,
+		CategoryID: webhook.CategoryID,
+		CreatedAt:  webhook.CreatedAt,
+		Enabled:    webhook.Enabled,
+		FeedID:     webhook.FeedID,
+		Keyword:    webhook.Keyword,
+		TagID:      webhook.TagID,
+		URL:        webhook.URL,
+	}
+}
+
+// filterID returns the filter of id, nil for 0
+func filterID(id int64) *int64 {
+	if id == 0 {
+		return nil
+	}
+
+	return &id
+}
+
+// applyWebhookInput validates input and applies it to webhook, the error response is written on failure
+func applyWebhookInput(c *gin.Context, user *models.User, input *APIWebhookInput, webhook *models.Webhook) bool {
+	if input.URL != nil {
+		u, err := url.Parse(*input.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(*input.URL) > 1023 {
+			c.JSON(routes.InvalidParameterError("url"))
+			return false
+		}
+		webhook.URL = *input.URL
+	}
+	if input.Secret != nil {
+		if *input.Secret == "" || len(*input.Secret) > 255 {
+			c.JSON(routes.InvalidParameterError("secret"))
+			return false
+		}
+		webhook.Secret = *input.Secret
+	}
+	if input.Keyword != nil {
+		keyword := strings.TrimSpace(*input.Keyword)
+		if len(keyword) > 255 {
+			c.JSON(routes.InvalidParameterError("keyword"))
+			return false
+		}
+		webhook.Keyword = keyword
+	}
+	if input.Enabled != nil {
+		webhook.Enabled = *input.Enabled
+	}
+
+	if input.FeedID != nil {
+		if webhook.FeedID = filterID(*input.FeedID); webhook.FeedID != nil {
+			subscription, err := models.GetSubscription(user.ID, *webhook.FeedID)
+			if err != nil {
+				c.JSON(routes.InternalServerError())
+				return false
+			}
+			if subscription == nil {
+				c.JSON(routes.NotFoundError("feed"))
+				return false
+			}
+		}
+	}
+	if input.CategoryID != nil {
+		if webhook.CategoryID = filterID(*input.CategoryID); webhook.CategoryID != nil &&
+			!apiCheckCategory(c, user, *webhook.CategoryID) {
+			return false
+		}
+	}
+	if input.TagID != nil {
+		if webhook.TagID = filterID(*input.TagID); webhook.TagID != nil {
+			tag, err := models.GetTag(user.ID, *webhook.TagID)
+			if err != nil {
+				c.JSON(routes.InternalServerError())
+				return false
+			}
+			if tag == nil {
+				c.JSON(routes.NotFoundError("tag"))
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// contextWebhook gets the webhook of user in path, the error response is written on failure
+func contextWebhook(c *gin.Context, user *models.User) (*models.Webhook, bool) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	webhook, err := models.GetWebhook(user.ID, id)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return nil, false
+	}
+	if webhook == nil {
+		c.JSON(routes.NotFoundError("webhook"))
+		return nil, false
+	}
+
+	return webhook, true
+}
+
+func apiListWebhooks(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	webhooks, err := models.ListWebhooks(user.ID)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	list := []*APIWebhook{}
+	for _, webhook := range webhooks {
+		list = append(list, apiWebhook(webhook))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": list,
+	})
+}
+
+func apiCreateWebhook(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	var input APIWebhookInput
+	if !bindAPIJSON(c, &input) {
+		return
+	}
+	if input.URL == nil {
+		c.JSON(routes.InvalidParameterError("url"))
+		return
+	}
+
+	webhook := &models.Webhook{
+		Enabled: true,
+		UserID:  user.ID,
+	}
+	if !applyWebhookInput(c, user, &input, webhook) {
+		return
+	}
+	if webhook.Secret == "" {
+		secret, err := utils.SecureRandomHex(32)
+		if err != nil {
+			c.JSON(routes.InternalServerError())
+			return
+		}
+		webhook.Secret = secret
+	}
+
+	if _, err := models.AddWebhook(webhook); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	res := apiWebhook(webhook)
+	res.Secret = webhook.Secret
+
+	c.Header("Location", fmt.Sprintf("%s/webhooks/%d", apiPrefix, webhook.ID))
+	c.JSON(http.StatusCreated, res)
+}
+
+func apiGetWebhook(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	webhook, ok := contextWebhook(c, user)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, apiWebhook(webhook))
+}
+
+func apiUpdateWebhook(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	webhook, ok := contextWebhook(c, user)
+	if !ok {
+		return
+	}
+
+	var input APIWebhookInput
+	if !bindAPIJSON(c, &input) {
+		return
+	}
+	if !applyWebhookInput(c, user, &input, webhook) {
+		return
+	}
+
+	if err := models.UpdateWebhook(webhook); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.JSON(http.StatusOK, apiWebhook(webhook))
+}
+
+func apiDeleteWebhook(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	webhook, ok := contextWebhook(c, user)
+	if !ok {
+		return
+	}
+
+	if _, err := models.DeleteWebhook(user.ID, webhook.ID); err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func apiListWebhookDeliveries(c *gin.Context) {
+	user, ok := contextUser(c)
+	if !ok {
+		return
+	}
+
+	webhook, ok := contextWebhook(c, user)
+	if !ok {
+		return
+	}
+
+	limit := apiDefaultDeliveryLimit
+	if v, ok := c.GetQuery("limit"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > apiMaxDeliveryLimit {
+			c.JSON(routes.InvalidParameterError("limit"))
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := models.ListWebhookDeliveries(webhook.ID, limit)
+	if err != nil {
+		c.JSON(routes.InternalServerError())
+		return
+	}
+
+	list := []*APIWebhookDelivery{}
+	for _, delivery := range deliveries {
+		item := &APIWebhookDelivery{
+			ID:             delivery.ID,
+			Attempts:       delivery.Attempts,
+			CreatedAt:      delivery.CreatedAt,
+			DeliveredAt:    delivery.DeliveredAt,
+			EntryID:        delivery.EntryID,
+			Event:          delivery.Event,
+			LastAttemptAt:  delivery.LastAttemptAt,
+			LastError:      delivery.LastError,
+			ResponseStatus: delivery.ResponseStatus,
+			Status:         delivery.Status,
+		}
+		if delivery.Status == models.WebhookDeliveryPending {
+			nextAttemptAt := delivery.NextAttemptAt
+			item.NextAttemptAt = &nextAttemptAt
+		}
+		list = append(list, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": list,
+	})
+}
@@ -0,0 +1,321 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"reader/internal/app/reader"
+	"reader/internal/app/reader/events"
+	"reader/internal/app/reader/models"
+	"reader/internal/pkg/search"
+)
+
+// MaxDryRunLimit newest entries evaluated by dry run at most
+const MaxDryRunLimit = 1000
+
+var (
+	// ErrInvalidAction action of rule is unknown or incomplete
+	ErrInvalidAction = errors.New("invalid action")
+	// ErrInvalidCondition condition of rule has an unknown field or operator, or an invalid regular expression
+	ErrInvalidCondition = errors.New("invalid condition")
+)
+
+// Matcher rule compiled for evaluation
+type Matcher struct {
+	Rule *models.Rule
+
+	conditions []*condition
+}
+
+type condition struct {
+	field  string
+	equals string // feed ID of feed equals conditions
+	match  func(string) bool
+}
+
+// Compile validates the conditions and actions of rule and compiles it
+func Compile(rule *models.Rule) (*Matcher, error) {
+	if len(rule.Conditions) == 0 {
+		return nil, fmt.Errorf("no conditions: %w", ErrInvalidCondition)
+	}
+	if len(rule.Actions) == 0 {
+		return nil, fmt.Errorf("no actions: %w", ErrInvalidAction)
+	}
+
+	m := &Matcher{Rule: rule}
+	for i, c := range rule.Conditions {
+		if c == nil {
+			return nil, fmt.Errorf("condition %d: empty: %w", i, ErrInvalidCondition)
+		}
+		switch c.Field {
+		case models.RuleFieldAuthor, models.RuleFieldCategory, models.RuleFieldContent,
+			models.RuleFieldFeed, models.RuleFieldLink, models.RuleFieldTitle:
+		default:
+			return nil, fmt.Errorf("condition %d: unknown field %q: %w", i, c.Field, ErrInvalidCondition)
+		}
+		if c.Value == "" {
+			return nil, fmt.Errorf("condition %d: empty value: %w", i, ErrInvalidCondition)
+		}
+
+		compiled := &condition{field: c.Field}
+		switch c.Operator {
+		case models.RuleOperatorContains:
+			value := strings.ToLower(c.Value)
+			compiled.match = func(s string) bool {
+				return strings.Contains(strings.ToLower(s), value)
+			}
+		case models.RuleOperatorEquals:
+			value := c.Value
+			compiled.match = func(s string) bool {
+				return strings.EqualFold(s, value)
+			}
+			if c.Field == models.RuleFieldFeed {
+				compiled.equals = value
+			}
+		case models.RuleOperatorMatches:
+			re, err := regexp.Compile(c.Value)
+			if err != nil {
+				return nil, fmt.Errorf("condition %d: %v: %w", i, err, ErrInvalidCondition)
+			}
+			compiled.match = re.MatchString
+		default:
+			return nil, fmt.Errorf("condition %d: unknown operator %q: %w", i, c.Operator, ErrInvalidCondition)
+		}
+		m.conditions = append(m.conditions, compiled)
+	}
+
+	for i, a := range rule.Actions {
+		if a == nil {
+			return nil, fmt.Errorf("action %d: empty: %w", i, ErrInvalidAction)
+		}
+		switch a.Type {
+		case models.RuleActionDelete, models.RuleActionNotify, models.RuleActionRead, models.RuleActionStar:
+		case models.RuleActionTag:
+			if a.TagID <= 0 {
+				return nil, fmt.Errorf("action %d: no tag: %w", i, ErrInvalidAction)
+			}
+		default:
+			return nil, fmt.Errorf("action %d: unknown type %q: %w", i, a.Type, ErrInvalidAction)
+		}
+	}
+
+	return m, nil
+}
+
+// Match returns true if entry matches all conditions, names are the subscription names of the rule owner
+func (m *Matcher) Match(entry *models.Entry, names *reader.FeedCategoryName) bool {
+	var content *string
+	for _, c := range m.conditions {
+		var value string
+		switch c.field {
+		case models.RuleFieldAuthor:
+			value = html.UnescapeString(entry.Author)
+		case models.RuleFieldCategory:
+			value = html.UnescapeString(names.CategoryName)
+		case models.RuleFieldContent:
+			if content == nil {
+				text := search.StripHTML(entry.Content)
+				content = &text
+			}
+			value = *content
+		case models.RuleFieldFeed:
+			if c.equals != "" && c.equals == strconv.FormatInt(entry.FeedID, 10) {
+				continue
+			}
+			value = html.UnescapeString(names.FeedName)
+		case models.RuleFieldLink:
+			value = html.UnescapeString(entry.Link)
+		case models.RuleFieldTitle:
+			value = html.UnescapeString(entry.Title)
+		}
+
+		if !c.match(value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// actions actions of matching rules of a user, merged
+type actions struct {
+	delete bool
+	notify []string // rule names
+	read   bool
+	star   bool
+	tagIDs []int64
+}
+
+func (a *actions) add(rule *models.Rule) {
+	for _, action := range rule.Actions {
+		switch action.Type {
+		case models.RuleActionDelete:
+			a.delete = true
+		case models.RuleActionNotify:
+			a.notify = append(a.notify, rule.Name)
+		case models.RuleActionRead:
+			a.read = true
+		case models.RuleActionStar:
+			a.star = true
+		case models.RuleActionTag:
+			a.tagIDs = append(a.tagIDs, action.TagID)
+		}
+	}
+}
+
+// apply applies actions to entry for user, tags which no longer exist are skipped
+func (a *actions) apply(userID int64, entry *models.Entry) error {
+	ids := []int64{entry.ID}
+
+	for _, tagID := range a.tagIDs {
+		tag, err := models.GetTag(userID, tagID)
+		if err != nil {
+			return err
+		}
+		if tag == nil {
+			continue
+		}
+		if err := models.AddTagForEntries(tag.ID, ids); err != nil {
+			return err
+		}
+	}
+	if a.read {
+		if _, err := models.MarkRead(userID, ids, true); err != nil {
+			return err
+		}
+	}
+	if a.star {
+		if _, err := models.MarkStarred(userID, ids, true); err != nil {
+			return err
+		}
+	}
+	if a.delete {
+		// deleted entries are not notified
+		if _, err := models.MarkHidden(userID, ids); err != nil {
+			return err
+		}
+		return nil
+	}
+	for _, name := range a.notify {
+		events.Publish(&events.Event{
+			Type:     events.TypeNotify,
+			EntryIDs: ids,
+			FeedID:   entry.FeedID,
+			Rule:     name,
+		}, userID)
+	}
+
+	return nil
+}
+
+// Apply evaluates the enabled rules of the subscribers of the feed of new entry and applies the actions of the
+// matching ones, rules which no longer compile are logged and skipped, failures of a subscriber are logged so that
+// the others are still applied
+func Apply(entry *models.Entry) error {
+	rules, err := models.ListEnabledRulesForFeed(entry.FeedID)
+	if err != nil {
+		return err
+	}
+
+	userRules := make(map[int64][]*models.Rule)
+	var userIDs []int64
+	for _, rule := range rules {
+		if _, ok := userRules[rule.UserID]; !ok {
+			userIDs = append(userIDs, rule.UserID)
+		}
+		userRules[rule.UserID] = append(userRules[rule.UserID], rule)
+	}
+
+	for _, userID := range userIDs {
+		names, err := models.GetFeedAndCategoryName(userID, entry.FeedID)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"user":  userID,
+				"feed":  entry.FeedID,
+				"error": err,
+			}).Error("Get feed of rules")
+			continue
+		}
+		if names == nil {
+			continue
+		}
+
+		matched := &actions{}
+		found := false
+		for _, rule := range userRules[userID] {
+			m, err := Compile(rule)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"user":  userID,
+					"rule":  rule.ID,
+					"error": err,
+				}).Warn("Compile rule")
+				continue
+			}
+			if m.Match(entry, names) {
+				matched.add(rule)
+				found = true
+			}
+		}
+		if !found {
+			continue
+		}
+
+		if err := matched.apply(userID, entry); err != nil {
+			log.WithFields(log.Fields{
+				"user":  userID,
+				"entry": entry.ID,
+				"error": err,
+			}).Error("Apply rules")
+		}
+	}
+
+	return nil
+}
+
+// DryRun evaluates rule against the newest entries of its user up to limit without applying actions,
+// returns the IDs of the matching entries newest first and the evaluated count
+func DryRun(rule *models.Rule, limit int) ([]int64, int, error) {
+	m, err := Compile(rule)
+	if err != nil {
+		return nil, 0, err
+	}
+	if limit <= 0 || limit > MaxDryRunLimit {
+		limit = MaxDryRunLimit
+	}
+
+	ids, _, err := models.ListEntryIDs(
+		models.UserScope(rule.UserID),
+		models.OrderScope(false),
+		models.CountScope(limit))
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(ids) == 0 {
+		return nil, 0, nil
+	}
+
+	entries, err := models.ListEntriesByIDs(rule.UserID, ids, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	names, err := models.GetFeedAndCategoryNames(rule.UserID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var matched []int64
+	for _, entry := range entries {
+		if feedNames, ok := names[entry.FeedID]; ok && m.Match(entry, feedNames) {
+			matched = append(matched, entry.ID)
+		}
+	}
+
+	return matched, len(entries), nil
+}
@@ -0,0 +1,73 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"reader/internal/app/reader"
+	"reader/internal/app/reader/models"
+)
+
+func TestCompile(t *testing.T) {
+	read := []*models.RuleAction{{Type: models.RuleActionRead}}
+	for _, rule := range []*models.Rule{
+		{Actions: read},
+		{Actions: read, Conditions: []*models.RuleCondition{{Field: "summary", Operator: "contains", Value: "a"}}},
+		{Actions: read, Conditions: []*models.RuleCondition{{Field: "title", Operator: "starts", Value: "a"}}},
+		{Actions: read, Conditions: []*models.RuleCondition{{Field: "title", Operator: "matches", Value: "("}}},
+		{Actions: read, Conditions: []*models.RuleCondition{{Field: "title", Operator: "contains"}}},
+	} {
+		_, err := Compile(rule)
+		assert.ErrorIs(t, err, ErrInvalidCondition)
+	}
+
+	title := []*models.RuleCondition{{Field: "title", Operator: "contains", Value: "a"}}
+	for _, actions := range [][]*models.RuleAction{
+		nil,
+		{{Type: "archive"}},
+		{{Type: models.RuleActionTag}},
+		{nil},
+	} {
+		_, err := Compile(&models.Rule{Actions: actions, Conditions: title})
+		assert.ErrorIs(t, err, ErrInvalidAction)
+	}
+
+	_, err := Compile(&models.Rule{Actions: []*models.RuleAction{{Type: models.RuleActionTag, TagID: 1}}, Conditions: title})
+	assert.NoError(t, err)
+}
+
+func TestMatch(t *testing.T) {
+	entry := &models.Entry{
+		Author:  "米哈游",
+		Content: "<p>服务器将于 &lt;10:00&gt; 进行<b>停服维护</b></p>",
+		FeedID:  7,
+		Link:    "https://example.com/news/1?a=1&amp;b=2",
+		Title:   "《崩坏3》6.0版本维护预告 &amp; 补偿",
+	}
+	names := &reader.FeedCategoryName{CategoryName: "Games", FeedName: "Honkai Impact 3"}
+
+	match := func(conditions ...*models.RuleCondition) bool {
+		m, err := Compile(&models.Rule{
+			Actions:    []*models.RuleAction{{Type: models.RuleActionRead}},
+			Conditions: conditions,
+		})
+		assert.NoError(t, err)
+		return m.Match(entry, names)
+	}
+
+	assert.True(t, match(
+		&models.RuleCondition{Field: "feed", Operator: "equals", Value: "honkai impact 3"},
+		&models.RuleCondition{Field: "title", Operator: "contains", Value: "维护"}))
+	assert.True(t, match(&models.RuleCondition{Field: "feed", Operator: "equals", Value: "7"}))
+	assert.False(t, match(&models.RuleCondition{Field: "feed", Operator: "contains", Value: "7"}))
+	assert.True(t, match(&models.RuleCondition{Field: "category", Operator: "equals", Value: "games"}))
+	assert.True(t, match(&models.RuleCondition{Field: "title", Operator: "matches", Value: `^《崩坏3》\d\.\d版本.* & 补偿$`}))
+	assert.True(t, match(&models.RuleCondition{Field: "content", Operator: "contains", Value: "<10:00> 进行 停服维护"}))
+	assert.False(t, match(&models.RuleCondition{Field: "content", Operator: "contains", Value: "<b>"}))
+	assert.True(t, match(&models.RuleCondition{Field: "link", Operator: "matches", Value: `a=1&b=2$`}))
+	assert.True(t, match(&models.RuleCondition{Field: "author", Operator: "equals", Value: "米哈游"}))
+	assert.False(t, match(
+		&models.RuleCondition{Field: "title", Operator: "contains", Value: "维护"},
+		&models.RuleCondition{Field: "author", Operator: "contains", Value: "鹰角"}))
+}
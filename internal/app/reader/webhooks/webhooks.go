@@ -0,0 +1,296 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"reader/internal/app/reader/models"
+	"reader/internal/pkg/fetch"
+	"reader/internal/pkg/utils"
+)
+
+// EventEntryCreated event of new entries
+const EventEntryCreated = "entry.created"
+
+// request headers
+const (
+	HeaderDelivery  = "X-Reader-Delivery"
+	HeaderEvent     = "X-Reader-Event"
+	HeaderSignature = "X-Reader-Signature" // sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+	HeaderTimestamp = "X-Reader-Timestamp" // unix seconds
+)
+
+const (
+	MaxAttempts = 8
+
+	baseBackoff = time.Minute
+	batchSize   = 20
+	jitterRatio = 0.1
+	lease       = 5 * time.Minute // claimed deliveries are retried after lease if the worker dies
+	maxBackoff  = 6 * time.Hour
+	tick        = 15 * time.Second
+	timeout     = 15 * time.Second
+)
+
+// Payload JSON body of webhook request
+type Payload struct {
+	Event     string        `json:"event"`
+	WebhookID int64         `json:"webhookId"`
+	Entry     *PayloadEntry `json:"entry"`
+}
+
+// PayloadEntry entry of payload
+type PayloadEntry struct {
+	ID        int64  `json:"id"`
+	FeedID    int64  `json:"feedId"`
+	FeedTitle string `json:"feedTitle"`
+	Title     string `json:"title"`
+	Author    string `json:"author"`
+	URL       string `json:"url"`
+	Content   string `json:"content"`
+	Published int64  `json:"published"`
+}
+
+// Sign returns the signature header value of body sent at timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Matches returns true if keyword of webhook matches entry
+func Matches(webhook *models.Webhook, entry *models.Entry) bool {
+	if webhook.Keyword == "" {
+		return true
+	}
+
+	keyword := strings.ToLower(webhook.Keyword)
+	return strings.Contains(strings.ToLower(html.UnescapeString(entry.Title)), keyword) ||
+		strings.Contains(strings.ToLower(entry.Content), keyword)
+}
+
+// Enqueue queues deliveries of new entry to the matching webhooks of its subscribers
+func Enqueue(entry *models.Entry) error {
+	webhooks, err := models.ListMatchingWebhooks(entry)
+	if err != nil {
+		return err
+	}
+
+	// the feed title is the subscription name of the webhook user, which may be renamed
+	feedTitles := make(map[int64]string)
+	var deliveries []*models.WebhookDelivery
+	for _, webhook := range webhooks {
+		if !Matches(webhook, entry) {
+			continue
+		}
+
+		feedTitle, ok := feedTitles[webhook.UserID]
+		if !ok {
+			subscription, err := models.GetSubscription(webhook.UserID, entry.FeedID)
+			if err != nil {
+				return err
+			}
+			if subscription == nil {
+				continue
+			}
+			feedTitle = html.UnescapeString(subscription.Name())
+			feedTitles[webhook.UserID] = feedTitle
+		}
+
+		body, err := json.Marshal(&Payload{
+			Event:     EventEntryCreated,
+			WebhookID: webhook.ID,
+			Entry: &PayloadEntry{
+				ID:        entry.ID,
+				FeedID:    entry.FeedID,
+				FeedTitle: feedTitle,
+				Title:     html.UnescapeString(entry.Title),
+				Author:    html.UnescapeString(entry.Author),
+				URL:       html.UnescapeString(entry.Link),
+				Content:   entry.Content,
+				Published: entry.Date.Unix(),
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		deliveries = append(deliveries, &models.WebhookDelivery{
+			EntryID:       entry.ID,
+			Event:         EventEntryCreated,
+			NextAttemptAt: time.Now(),
+			Payload:       string(body),
+			Status:        models.WebhookDeliveryPending,
+			WebhookID:     webhook.ID,
+		})
+	}
+
+	return models.AddWebhookDeliveries(deliveries)
+}
+
+// jitter spreads d randomly by jitterRatio in both directions
+func jitter(d time.Duration) time.Duration {
+	delta := time.Duration(float64(d) * jitterRatio)
+	if delta <= 0 {
+		return d
+	}
+
+	return d - delta + time.Duration(rand.Int63n(int64(2*delta)))
+}
+
+// backoff returns the delay before the next attempt after failed attempts
+func backoff(attempts int32) time.Duration {
+	delay := baseBackoff
+	for i := int32(1); i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+
+	return delay
+}
+
+// Sender sends webhook requests
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender creates sender, nil client for a default one
+func NewSender(client *http.Client) *Sender {
+	if client == nil {
+		client = &http.Client{Timeout: timeout}
+	}
+
+	return &Sender{client: client}
+}
+
+// send posts payload of delivery, returns response status
+func (s *Sender) send(ctx context.Context, delivery *models.WebhookDelivery, now time.Time) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := now.Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", fetch.DefaultUserAgent)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderSignature, Sign(delivery.Webhook.Secret, timestamp, body))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// drain a little so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Deliver attempts delivery at now and updates its state,
+// failed attempts back off exponentially until MaxAttempts
+func (s *Sender) Deliver(ctx context.Context, delivery *models.WebhookDelivery, now time.Time) {
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+
+	var status int
+	var err error
+	if delivery.Webhook == nil || !delivery.Webhook.Enabled {
+		err = fmt.Errorf("webhook disabled")
+	} else {
+		status, err = s.send(ctx, delivery, now)
+	}
+	delivery.ResponseStatus = status
+
+	if err == nil {
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		delivery.Status = models.WebhookDeliveryDelivered
+		return
+	}
+
+	delivery.LastError = utils.Truncate(err.Error(), 1023)
+	if delivery.Webhook == nil || !delivery.Webhook.Enabled || delivery.Attempts >= MaxAttempts {
+		delivery.Status = models.WebhookDeliveryFailed
+		return
+	}
+	delivery.NextAttemptAt = now.Add(jitter(backoff(delivery.Attempts)))
+}
+
+// Start starts the delivery worker
+func Start() {
+	s := NewSender(nil)
+
+	go func() {
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+
+		for {
+			s.run(context.Background())
+			<-ticker.C
+		}
+	}()
+}
+
+// run delivers due deliveries until none is left
+func (s *Sender) run(ctx context.Context) {
+	for {
+		deliveries, err := models.ClaimWebhookDeliveries(time.Now(), batchSize, lease)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Error("Claim webhook deliveries")
+			return
+		}
+
+		for _, delivery := range deliveries {
+			s.Deliver(ctx, delivery, time.Now())
+			if delivery.Status != models.WebhookDeliveryDelivered {
+				log.WithFields(log.Fields{
+					"webhook":  delivery.WebhookID,
+					"delivery": delivery.ID,
+					"attempts": delivery.Attempts,
+					"error":    delivery.LastError,
+				}).Warn("Webhook delivery failed")
+			}
+
+			if err := models.UpdateWebhookDelivery(delivery); err != nil {
+				log.WithFields(log.Fields{
+					"delivery": delivery.ID,
+					"error":    err,
+				}).Error("Update webhook delivery")
+			}
+		}
+
+		if len(deliveries) < batchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"reader/internal/app/reader/models"
+)
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t,
+		"sha256=b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163",
+		Sign("secret", 1700000000, []byte("{}")))
+}
+
+func TestMatches(t *testing.T) {
+	entry := &models.Entry{Title: "Tom &amp; Jerry", Content: "<p>Cartoon</p>"}
+
+	assert.True(t, Matches(&models.Webhook{}, entry))
+	assert.True(t, Matches(&models.Webhook{Keyword: "tom & jerry"}, entry))
+	assert.True(t, Matches(&models.Webhook{Keyword: "CARTOON"}, entry))
+	assert.False(t, Matches(&models.Webhook{Keyword: "movie"}, entry))
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, baseBackoff, backoff(1))
+	assert.Equal(t, 4*baseBackoff, backoff(3))
+	assert.Equal(t, maxBackoff, backoff(20))
+}
+
+func TestDeliver(t *testing.T) {
+	status := http.StatusOK
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	s := NewSender(server.Client())
+	now := time.Unix(1700000000, 0)
+	webhook := &models.Webhook{ID: 1, Enabled: true, Secret: "secret", URL: server.URL}
+
+	delivery := &models.WebhookDelivery{
+		ID:        7,
+		Event:     EventEntryCreated,
+		Payload:   `{"event":"entry.created"}`,
+		Status:    models.WebhookDeliveryPending,
+		Webhook:   webhook,
+		WebhookID: webhook.ID,
+	}
+	s.Deliver(context.Background(), delivery, now)
+	assert.Equal(t, models.WebhookDeliveryDelivered, delivery.Status)
+	assert.Equal(t, int32(1), delivery.Attempts)
+	assert.Equal(t, http.StatusOK, delivery.ResponseStatus)
+	assert.Equal(t, &now, delivery.DeliveredAt)
+	assert.Equal(t, delivery.Payload, string(body))
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Equal(t, "7", header.Get(HeaderDelivery))
+	assert.Equal(t, EventEntryCreated, header.Get(HeaderEvent))
+	assert.Equal(t, strconv.FormatInt(now.Unix(), 10), header.Get(HeaderTimestamp))
+	assert.Equal(t, Sign("secret", now.Unix(), body), header.Get(HeaderSignature))
+
+	status = http.StatusInternalServerError
+	delivery = &models.WebhookDelivery{
+		Payload: "{}",
+		Status:  models.WebhookDeliveryPending,
+		Webhook: webhook,
+	}
+	s.Deliver(context.Background(), delivery, now)
+	assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+	assert.Equal(t, http.StatusInternalServerError, delivery.ResponseStatus)
+	assert.NotEmpty(t, delivery.LastError)
+	assert.Nil(t, delivery.DeliveredAt)
+	delta := time.Duration(float64(baseBackoff) * jitterRatio)
+	assert.GreaterOrEqual(t, delivery.NextAttemptAt.Sub(now), baseBackoff-delta)
+	assert.LessOrEqual(t, delivery.NextAttemptAt.Sub(now), baseBackoff+delta)
+
+	for delivery.Status == models.WebhookDeliveryPending {
+		s.Deliver(context.Background(), delivery, now)
+	}
+	assert.Equal(t, models.WebhookDeliveryFailed, delivery.Status)
+	assert.Equal(t, int32(MaxAttempts), delivery.Attempts)
+
+	webhook.Enabled = false
+	delivery = &models.WebhookDelivery{
+		Payload: "{}",
+		Status:  models.WebhookDeliveryPending,
+		Webhook: webhook,
+	}
+	s.Deliver(context.Background(), delivery, now)
+	assert.Equal(t, models.WebhookDeliveryFailed, delivery.Status)
+}
@@ -0,0 +1,181 @@
+package fetch
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+// default options
+const (
+	DefaultMaxBodySize = 16 << 20 // bytes
+	DefaultTimeout     = 30 * time.Second
+	DefaultUserAgent   = "OnionReader (+https://github.com/onionyst/reader)"
+)
+
+// errors
+var (
+	ErrNotModified = errors.New("not modified")
+	ErrTooLarge    = errors.New("response body too large")
+)
+
+// Options client options
+type Options struct {
+	MaxBodySize int64 // bytes, 0 for default
+	Proxy       string
+	Timeout     time.Duration // 0 for default
+	Transport   http.RoundTripper
+	UserAgent   string
+}
+
+// Validators conditional request validators
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// Response fetched response
+type Response struct {
+	Body        []byte
+	ContentType string
+	URL         string // final URL after redirects
+	Validators  Validators
+}
+
+// Client HTTP client for fetching feeds
+type Client struct {
+	client      *http.Client
+	maxBodySize int64
+	userAgent   string
+}
+
+// New creates a client, proxy falls back to the environment settings when empty
+func New(options *Options) (*Client, error) {
+	if options == nil {
+		options = &Options{}
+	}
+
+	transport := options.Transport
+	if transport == nil {
+		proxy := http.ProxyFromEnvironment
+		if options.Proxy != "" {
+			proxyURL, err := url.Parse(options.Proxy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid proxy: %w", err)
+			}
+			proxy = http.ProxyURL(proxyURL)
+		}
+
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.Proxy = proxy
+		transport = t
+	}
+
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	maxBodySize := options.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+
+	userAgent := options.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+
+	return &Client{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+		},
+		maxBodySize: maxBodySize,
+		userAgent:   userAgent,
+	}, nil
+}
+
+// Get gets URL, sends conditional headers if validators are given,
+// returns ErrNotModified if the server answers 304
+func (c *Client) Get(ctx context.Context, url string, validators *Validators) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
+	req.Header.Set("User-Agent", c.userAgent)
+	if validators != nil {
+		if validators.ETag != "" {
+			req.Header.Set("If-None-Match", validators.ETag)
+		}
+		if validators.LastModified != "" {
+			req.Header.Set("If-Modified-Since", validators.LastModified)
+		}
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, ErrNotModified
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if resp.ContentLength > c.maxBodySize {
+		return nil, ErrTooLarge
+	}
+
+	body, err := decodeBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, c.maxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > c.maxBodySize {
+		return nil, ErrTooLarge
+	}
+
+	return &Response{
+		Body:        data,
+		ContentType: resp.Header.Get("Content-Type"),
+		URL:         resp.Request.URL.String(),
+		Validators: Validators{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		},
+	}, nil
+}
+
+func decodeBody(resp *http.Response) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return io.NopCloser(resp.Body), nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(resp.Body)
+	case "deflate":
+		return zlib.NewReader(resp.Body)
+	case "br":
+		return io.NopCloser(brotli.NewReader(resp.Body)), nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %s", resp.Header.Get("Content-Encoding"))
+	}
+}
@@ -0,0 +1,96 @@
+package fetch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetConditional(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-agent", r.Header.Get("User-Agent"))
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 04 Jul 2022 00:00:00 GMT")
+		w.Write([]byte("feed"))
+	}))
+	defer server.Close()
+
+	client, err := New(&Options{UserAgent: "test-agent"})
+	assert.Nil(t, err)
+
+	resp, err := client.Get(context.Background(), server.URL, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "feed", string(resp.Body))
+	assert.Equal(t, `"v1"`, resp.Validators.ETag)
+	assert.Equal(t, "Mon, 04 Jul 2022 00:00:00 GMT", resp.Validators.LastModified)
+
+	_, err = client.Get(context.Background(), server.URL, &resp.Validators)
+	assert.ErrorIs(t, err, ErrNotModified)
+}
+
+func TestGetEncoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		switch r.URL.Path {
+		case "/gzip":
+			w.Header().Set("Content-Encoding", "gzip")
+			gw := gzip.NewWriter(&buf)
+			gw.Write([]byte("gzip body"))
+			gw.Close()
+		case "/br":
+			w.Header().Set("Content-Encoding", "br")
+			bw := brotli.NewWriter(&buf)
+			bw.Write([]byte("brotli body"))
+			bw.Close()
+		}
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	client, err := New(nil)
+	assert.Nil(t, err)
+
+	resp, err := client.Get(context.Background(), server.URL+"/gzip", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "gzip body", string(resp.Body))
+
+	resp, err = client.Get(context.Background(), server.URL+"/br", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "brotli body", string(resp.Body))
+}
+
+func TestGetLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/large":
+			w.Write([]byte(strings.Repeat("x", 64)))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := New(&Options{MaxBodySize: 32})
+	assert.Nil(t, err)
+
+	_, err = client.Get(context.Background(), server.URL+"/large", nil)
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = client.Get(context.Background(), server.URL+"/missing", nil)
+	assert.NotNil(t, err)
+
+	_, err = New(&Options{Proxy: "://invalid"})
+	assert.NotNil(t, err)
+}
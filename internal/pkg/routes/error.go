@@ -47,6 +47,16 @@ func InternalServerError() (int, map[string]interface{}) {
 	}
 }
 
+// NotConfiguredError generates an error for a server setting which is required but missing
+func NotConfiguredError(setting string) (int, map[string]interface{}) {
+	return http.StatusServiceUnavailable, gin.H{
+		"error": Error{
+			Code:    "NotConfigured",
+			Message: fmt.Sprintf("Server setting is missing: %s.", setting),
+		},
+	}
+}
+
 // NotFoundError generates a not found error
 func NotFoundError(target string) (int, map[string]interface{}) {
 	return http.StatusNotFound, gin.H{
@@ -56,3 +66,33 @@ func NotFoundError(target string) (int, map[string]interface{}) {
 		},
 	}
 }
+
+// ForbiddenError generates a forbidden error
+func ForbiddenError(target string) (int, map[string]interface{}) {
+	return http.StatusForbidden, gin.H{
+		"error": Error{
+			Code:    "Forbidden",
+			Message: fmt.Sprintf("Failed to modify resource: %s.", target),
+		},
+	}
+}
+
+// TooManyRequestsError generates a too many requests error
+func TooManyRequestsError() (int, map[string]interface{}) {
+	return http.StatusTooManyRequests, gin.H{
+		"error": Error{
+			Code:    "TooManyRequests",
+			Message: "Too many failed attempts, retry later.",
+		},
+	}
+}
+
+// ConflictError generates a conflict error
+func ConflictError(target string) (int, map[string]interface{}) {
+	return http.StatusConflict, gin.H{
+		"error": Error{
+			Code:    "Conflict",
+			Message: fmt.Sprintf("Resource already exists: %s.", target),
+		},
+	}
+}
@@ -0,0 +1,106 @@
+package search
+
+import (
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// DefaultConfig default text search configuration, it does not stem so it suits mixed languages
+const DefaultConfig = "simple"
+
+// Config returns the Postgres text search configuration of SEARCH_CONFIG
+func Config() string {
+	if v := strings.TrimSpace(os.Getenv("SEARCH_CONFIG")); v != "" {
+		return v
+	}
+
+	return DefaultConfig
+}
+
+// isCJK returns true for characters of languages written without spaces
+func isCJK(r rune) bool {
+	return r == 'ー' || unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// isWord returns true for characters of words which need a space to be split from CJK runs
+func isWord(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Segment splits runs of CJK characters into overlapping bigrams separated by spaces, other text is kept,
+// Postgres parsers treat a whole CJK run as one word so it could only be matched as a whole otherwise
+func Segment(text string) string {
+	var b strings.Builder
+	var run []rune
+	var last rune
+
+	flush := func() {
+		if len(run) == 1 {
+			b.WriteRune(run[0])
+		}
+		for i := 0; i+1 < len(run); i++ {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(run[i])
+			b.WriteRune(run[i+1])
+		}
+		run = run[:0]
+	}
+
+	for _, r := range text {
+		if isCJK(r) {
+			if len(run) == 0 && isWord(last) {
+				b.WriteByte(' ')
+			}
+			run = append(run, r)
+		} else {
+			if len(run) > 0 {
+				flush()
+				if isWord(r) {
+					b.WriteByte(' ')
+				}
+			}
+			b.WriteRune(r)
+		}
+		last = r
+	}
+	flush()
+
+	return b.String()
+}
+
+// StripHTML returns the text of HTML content, elements are separated by spaces
+func StripHTML(content string) string {
+	var b strings.Builder
+
+	skip := 0
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return strings.Join(strings.Fields(b.String()), " ")
+		case html.TextToken:
+			if skip == 0 {
+				b.Write(z.Text())
+			}
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			if tag := string(name); tag == "script" || tag == "style" {
+				skip++
+			}
+			b.WriteByte(' ')
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if tag := string(name); (tag == "script" || tag == "style") && skip > 0 {
+				skip--
+			}
+			b.WriteByte(' ')
+		case html.SelfClosingTagToken:
+			b.WriteByte(' ')
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegment(t *testing.T) {
+	assert.Equal(t, "hello world", Segment("hello world"))
+	assert.Equal(t, "原神 神版 版本", Segment("原神版本"))
+	assert.Equal(t, "原神 3.0 版本", Segment("原神3.0版本"))
+	assert.Equal(t, "明日 日方 方舟 news", Segment("明日方舟 news"))
+	assert.Equal(t, "崩", Segment("崩"))
+	assert.Equal(t, `"原神 神版 版本" -活动`, Segment(`"原神版本" -活动`))
+	assert.Equal(t, "アー ーク クナ ナイ イツ", Segment("アークナイツ"))
+}
+
+func TestStripHTML(t *testing.T) {
+	assert.Equal(t, "Title A & B text", StripHTML(`<h1>Title</h1><p>A &amp; B<br/>text</p>`))
+	assert.Equal(t, "before after", StripHTML(`before<script>var x = "<p>";</script><style>p {}</style>after`))
+	assert.Equal(t, "plain", StripHTML("plain"))
+}
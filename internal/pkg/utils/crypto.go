@@ -1,7 +1,11 @@
 package utils
 
 import (
+	"crypto/md5"
+	"crypto/rand"
 	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 
 	"golang.org/x/crypto/bcrypt"
@@ -21,7 +25,26 @@ func VerifyPassword(password, hash string) bool {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
 }
 
+// Md5 generates md5 hash for plain string
+func Md5(plain string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(plain)))
+}
+
 // Sha1 generates sha1 hash for plain string
 func Sha1(plain string) string {
 	return fmt.Sprintf("%x", sha1.Sum([]byte(plain)))
 }
+
+// Sha256 generates sha256 hash for plain string
+func Sha256(plain string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(plain)))
+}
+
+// SecureRandomHex generates a hex string of n cryptographically secure random bytes
+func SecureRandomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -28,6 +28,15 @@ func Trim(s string) string {
 	return strings.Trim(s, "\t\n\r\x00\x0B")
 }
 
+// Truncate truncates string to at most n characters
+func Truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
+
 // UnescapeUnicode un-escapes unicode string
 func UnescapeUnicode(s string) (string, error) {
 	s, err := strconv.Unquote(strings.ReplaceAll(strconv.Quote(s), `\\u`, `\u`))